@@ -0,0 +1,101 @@
+package polymark
+
+import (
+	"errors"
+	"math"
+
+	"zappem.net/pub/math/polygon"
+)
+
+// ErrBadSegment indicates a Bezier path segment that does not hold 1,
+// 2 or 3 points.
+var ErrBadSegment = errors.New("bezier segment needs 1, 2 or 3 points")
+
+// maxDepth limits the recursive subdivision of Bezier curves.
+const maxDepth = 16
+
+// distance returns the distance of p from the line segment a-b.
+func distance(p, a, b polygon.Point) float64 {
+	dX, dY := b.X-a.X, b.Y-a.Y
+	l2 := dX*dX + dY*dY
+	if l2 == 0 {
+		return math.Hypot(p.X-a.X, p.Y-a.Y)
+	}
+	t := ((p.X-a.X)*dX + (p.Y-a.Y)*dY) / l2
+	t = math.Max(0, math.Min(1, t))
+	return math.Hypot(p.X-a.X-t*dX, p.Y-a.Y-t*dY)
+}
+
+// mid returns the point halfway between a and b.
+func mid(a, b polygon.Point) polygon.Point {
+	return polygon.Point{X: (a.X + b.X) / 2, Y: (a.Y + b.Y) / 2}
+}
+
+// cubic appends to pts the points of a straight line approximation of
+// the cubic Bezier curve from p0 to p3 with control points p1 and
+// p2. The p0 point is not appended. The curve is subdivided until
+// the control points lie within pen.Scribe/4 of the chord of each
+// piece.
+func (pen *Pen) cubic(pts []polygon.Point, p0, p1, p2, p3 polygon.Point, depth int) []polygon.Point {
+	tol := pen.Scribe / 4
+	if depth >= maxDepth || (distance(p1, p0, p3) <= tol && distance(p2, p0, p3) <= tol) {
+		return append(pts, p3)
+	}
+	p01, p12, p23 := mid(p0, p1), mid(p1, p2), mid(p2, p3)
+	p012, p123 := mid(p01, p12), mid(p12, p23)
+	m := mid(p012, p123)
+	pts = pen.cubic(pts, p0, p01, p012, m, depth+1)
+	return pen.cubic(pts, m, p123, p23, p3, depth+1)
+}
+
+// quad appends to pts the points of a straight line approximation of
+// the quadratic Bezier curve from p0 to p2 with control point p1. The
+// p0 point is not appended.
+func (pen *Pen) quad(pts []polygon.Point, p0, p1, p2 polygon.Point) []polygon.Point {
+	c1 := p0.AddX(p1.AddX(p0, -1), 2.0/3)
+	c2 := p2.AddX(p1.AddX(p2, -1), 2.0/3)
+	return pen.cubic(pts, p0, c1, c2, p2, 0)
+}
+
+// Quad constructs the outline of a line of the specified width that
+// follows the quadratic Bezier curve from p0 to p2 with control point
+// p1. The curve is approximated by straight segments that deviate
+// from it by no more than pen.Scribe/4, and is rendered with
+// (*Pen).Stroke, with the ends capped as indicated by caps.
+func (pen *Pen) Quad(s *polygon.Shapes, p0, p1, p2 polygon.Point, width float64, caps Caps) *polygon.Shapes {
+	return pen.Stroke(s, pen.quad([]polygon.Point{p0}, p0, p1, p2), width, caps)
+}
+
+// Cubic constructs the outline of a line of the specified width that
+// follows the cubic Bezier curve from p0 to p3 with control points
+// p1 and p2. The curve is approximated and rendered as for
+// (*Pen).Quad.
+func (pen *Pen) Cubic(s *polygon.Shapes, p0, p1, p2, p3 polygon.Point, width float64, caps Caps) *polygon.Shapes {
+	return pen.Stroke(s, pen.cubic([]polygon.Point{p0}, p0, p1, p2, p3, 0), width, caps)
+}
+
+// BezierPath constructs the outline of a line of the specified width
+// that starts at start and follows the sequence of segments, segs.
+// Each segment continues from the end of the previous one: a single
+// point is a straight line to that point, two points are the control
+// and end points of a quadratic Bezier curve, and three points are
+// the two control points and end point of a cubic Bezier curve. The
+// whole path is rendered as for (*Pen).Quad, with corners joined in
+// the pen.Join style.
+func (pen *Pen) BezierPath(s *polygon.Shapes, start polygon.Point, segs [][]polygon.Point, width float64, caps Caps) (*polygon.Shapes, error) {
+	pts := []polygon.Point{start}
+	for _, seg := range segs {
+		from := pts[len(pts)-1]
+		switch len(seg) {
+		case 1:
+			pts = append(pts, seg[0])
+		case 2:
+			pts = pen.quad(pts, from, seg[0], seg[1])
+		case 3:
+			pts = pen.cubic(pts, from, seg[0], seg[1], seg[2], 0)
+		default:
+			return s, ErrBadSegment
+		}
+	}
+	return pen.Stroke(s, pts, width, caps), nil
+}
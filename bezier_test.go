@@ -0,0 +1,76 @@
+package polymark
+
+import (
+	"math"
+	"testing"
+
+	"zappem.net/pub/math/polygon"
+)
+
+func TestFlatten(t *testing.T) {
+	pen := &Pen{Scribe: .2}
+	p0, p1, p2, p3 := polygon.Point{0, 0}, polygon.Point{5, 20}, polygon.Point{15, -20}, polygon.Point{20, 0}
+	ts := []struct {
+		pts []polygon.Point
+		at  func(t float64) polygon.Point
+	}{
+		{
+			pts: pen.quad([]polygon.Point{p0}, p0, p1, p3),
+			at: func(t float64) polygon.Point {
+				u := 1 - t
+				return polygon.Point{}.AddX(p0, u*u).AddX(p1, 2*u*t).AddX(p3, t*t)
+			},
+		},
+		{
+			pts: pen.cubic([]polygon.Point{p0}, p0, p1, p2, p3, 0),
+			at: func(t float64) polygon.Point {
+				u := 1 - t
+				return polygon.Point{}.AddX(p0, u*u*u).AddX(p1, 3*u*u*t).AddX(p2, 3*u*t*t).AddX(p3, t*t*t)
+			},
+		},
+	}
+	for i, v := range ts {
+		if len(v.pts) < 8 {
+			t.Errorf("[%d] too few points: %v", i, v.pts)
+		}
+		if !polygon.MatchPoint(v.pts[0], p0) || !polygon.MatchPoint(v.pts[len(v.pts)-1], p3) {
+			t.Errorf("[%d] bad end points: %v", i, v.pts)
+		}
+		for j := 0; j <= 100; j++ {
+			pt := v.at(float64(j) / 100)
+			d := math.Inf(1)
+			for k := 1; k < len(v.pts); k++ {
+				d = math.Min(d, distance(pt, v.pts[k-1], v.pts[k]))
+			}
+			if d > pen.Scribe/4 {
+				t.Errorf("[%d] curve point %v is %g from approximation", i, pt, d)
+			}
+		}
+	}
+}
+
+func TestBezierPath(t *testing.T) {
+	pen := &Pen{Scribe: 1}
+	s := pen.Cubic(nil, polygon.Point{0, 0}, polygon.Point{2, 0}, polygon.Point{8, 0}, polygon.Point{10, 0}, 2, Caps{})
+	if len(s.P) != 1 || len(s.P[0].PS) != 4 {
+		t.Fatalf("straight cubic should be a rectangle: %v", s.P)
+	}
+	s, err := pen.BezierPath(nil, polygon.Point{0, 0}, [][]polygon.Point{
+		{{10, 0}},
+		{{20, 0}, {20, 10}},
+		{{20, 20}, {0, 20}, {0, 10}},
+	}, 2, Caps{Start: CapRound, End: CapRound})
+	if err != nil {
+		t.Fatalf("path failed: %v", err)
+	}
+	if len(s.P) != 1 {
+		t.Fatalf("got %d polygons, want 1", len(s.P))
+	}
+	ll, tr := s.BB()
+	if math.Abs(ll.X+1) > .05 || math.Abs(ll.Y+1) > .05 || math.Abs(tr.X-21) > .05 || math.Abs(tr.Y-18.5) > .05 {
+		t.Errorf("got BB=%v,%v", ll, tr)
+	}
+	if _, err := pen.BezierPath(nil, polygon.Point{0, 0}, [][]polygon.Point{{}}, 2, Caps{}); err != ErrBadSegment {
+		t.Errorf("empty segment got err=%v, want %v", err, ErrBadSegment)
+	}
+}
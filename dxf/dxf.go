@@ -0,0 +1,298 @@
+// Package dxf exchanges polygon outlines and single line drawings
+// with CAD programs in the AutoCAD DXF format.
+package dxf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"zappem.net/pub/math/polygon"
+)
+
+// DefaultLayer is the layer name used for a Layer with no Name.
+const DefaultLayer = "OUTLINE"
+
+// HoleSuffix is appended to the Name of a Layer to name the layer
+// of its holes, when the Layer has no Holes name.
+const HoleSuffix = "-HOLES"
+
+// Layer holds a set of polygon shapes and the names of the DXF
+// layers on which they are written.
+type Layer struct {
+	// Name is the DXF layer that holds the outer boundaries of
+	// the shapes, and Holes is the layer that holds their holes.
+	Name, Holes string
+
+	// Color and HoleColor are the AutoCAD color index values of
+	// the two layers. Zero values imply 1 (red) and 5 (blue)
+	// respectively.
+	Color, HoleColor int
+
+	// Shapes are the polygons written to the layers.
+	Shapes *polygon.Shapes
+}
+
+// Config holds the drawing configuration for Write.
+type Config struct {
+	// Units is the $INSUNITS drawing units code. For example, 1
+	// is inches and 4 is millimeters. Zero leaves the units
+	// unspecified.
+	Units int
+}
+
+// writer writes DXF group code and value pairs, and allocates the
+// handles that identify each object of the drawing.
+type writer struct {
+	b      *bytes.Buffer
+	handle int64
+}
+
+// pair writes a group code and value pair.
+func (w *writer) pair(code int, value string) {
+	fmt.Fprintf(w.b, "%3d\n%s\n", code, value)
+}
+
+// num writes a group code and numerical value pair.
+func (w *writer) num(code int, v float64) {
+	w.pair(code, strconv.FormatFloat(v, 'f', -1, 64))
+}
+
+// next allocates a new handle.
+func (w *writer) next() string {
+	w.handle++
+	return strings.ToUpper(strconv.FormatInt(w.handle, 16))
+}
+
+// object writes the start of an object of type kind, with a new
+// handle, owned by the object with handle owner, followed by the
+// subclass markers. The handle is returned.
+func (w *writer) object(kind, owner string, subclasses ...string) string {
+	h := w.next()
+	w.start(kind, h, owner, subclasses...)
+	return h
+}
+
+// start writes the start of an object of type kind, with handle h,
+// as per object. DIMSTYLE objects record their handle with group
+// code 105 rather than 5.
+func (w *writer) start(kind, h, owner string, subclasses ...string) {
+	w.pair(0, kind)
+	if kind == "DIMSTYLE" {
+		w.pair(105, h)
+	} else {
+		w.pair(5, h)
+	}
+	w.pair(330, owner)
+	for _, sub := range subclasses {
+		w.pair(100, sub)
+	}
+}
+
+// table writes a symbol table, name, holding the n records written
+// by records, which is passed the handle of the table.
+func (w *writer) table(name string, n int, records func(table string)) {
+	h := w.next()
+	w.pair(0, "TABLE")
+	w.pair(2, name)
+	w.pair(5, h)
+	w.pair(330, "0")
+	w.pair(100, "AcDbSymbolTable")
+	w.pair(70, strconv.Itoa(n))
+	if name == "DIMSTYLE" {
+		w.pair(100, "AcDbDimStyleTable")
+	}
+	if records != nil {
+		records(h)
+	}
+	w.pair(0, "ENDTAB")
+}
+
+// layerNames returns the outer boundary and hole layer names and
+// colors for l.
+func (l Layer) layerNames() (name, holes string, color, holeColor int) {
+	name, holes, color, holeColor = l.Name, l.Holes, l.Color, l.HoleColor
+	if name == "" {
+		name = DefaultLayer
+	}
+	if holes == "" {
+		holes = name + HoleSuffix
+	}
+	if color == 0 {
+		color = 1
+	}
+	if holeColor == 0 {
+		holeColor = 5
+	}
+	return
+}
+
+// Write writes an AutoCAD 2000 (AC1015) DXF drawing to out. Every
+// polygon of each of the layers is written as a closed LWPOLYLINE
+// entity in model space, on the Name layer, or the Holes layer for
+// polygons that are holes. The drawing also holds the tables, blocks
+// and objects that the format requires. A nil cfg implies a zero
+// Config.
+func Write(out io.Writer, cfg *Config, layers ...Layer) error {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	w := &writer{b: &bytes.Buffer{}}
+
+	type def struct {
+		name  string
+		color int
+	}
+	defs := []def{{"0", 7}}
+	seen := map[string]bool{"0": true}
+	for _, l := range layers {
+		name, holes, color, holeColor := l.layerNames()
+		for _, d := range []def{{name, color}, {holes, holeColor}} {
+			if !seen[d.name] {
+				seen[d.name] = true
+				defs = append(defs, d)
+			}
+		}
+	}
+
+	// The header, which records the next free handle, is written
+	// once the rest of the drawing is complete.
+	w.pair(0, "SECTION")
+	w.pair(2, "CLASSES")
+	w.pair(0, "ENDSEC")
+
+	w.pair(0, "SECTION")
+	w.pair(2, "TABLES")
+	w.table("VPORT", 0, nil)
+	w.table("LTYPE", 3, func(table string) {
+		for _, lt := range [][2]string{{"ByBlock", ""}, {"ByLayer", ""}, {"Continuous", "Solid line"}} {
+			w.object("LTYPE", table, "AcDbSymbolTableRecord", "AcDbLinetypeTableRecord")
+			w.pair(2, lt[0])
+			w.pair(70, "0")
+			w.pair(3, lt[1])
+			w.pair(72, "65")
+			w.pair(73, "0")
+			w.num(40, 0)
+		}
+	})
+	w.table("LAYER", len(defs), func(table string) {
+		for _, d := range defs {
+			w.object("LAYER", table, "AcDbSymbolTableRecord", "AcDbLayerTableRecord")
+			w.pair(2, d.name)
+			w.pair(70, "0")
+			w.pair(62, strconv.Itoa(d.color))
+			w.pair(6, "Continuous")
+		}
+	})
+	w.table("STYLE", 1, func(table string) {
+		w.object("STYLE", table, "AcDbSymbolTableRecord", "AcDbTextStyleTableRecord")
+		w.pair(2, "Standard")
+		w.pair(70, "0")
+		w.num(40, 0)
+		w.num(41, 1)
+		w.num(50, 0)
+		w.pair(71, "0")
+		w.num(42, 2.5)
+		w.pair(3, "txt")
+		w.pair(4, "")
+	})
+	w.table("VIEW", 0, nil)
+	w.table("UCS", 0, nil)
+	w.table("APPID", 1, func(table string) {
+		w.object("APPID", table, "AcDbSymbolTableRecord", "AcDbRegAppTableRecord")
+		w.pair(2, "ACAD")
+		w.pair(70, "0")
+	})
+	w.table("DIMSTYLE", 1, func(table string) {
+		w.object("DIMSTYLE", table, "AcDbSymbolTableRecord", "AcDbDimStyleTableRecord")
+		w.pair(2, "Standard")
+		w.pair(70, "0")
+	})
+	spaces := []string{"*Model_Space", "*Paper_Space"}
+	var records []string
+	w.table("BLOCK_RECORD", len(spaces), func(table string) {
+		for _, name := range spaces {
+			records = append(records, w.object("BLOCK_RECORD", table, "AcDbSymbolTableRecord", "AcDbBlockTableRecord"))
+			w.pair(2, name)
+		}
+	})
+	w.pair(0, "ENDSEC")
+
+	w.pair(0, "SECTION")
+	w.pair(2, "BLOCKS")
+	for i, name := range spaces {
+		w.object("BLOCK", records[i], "AcDbEntity")
+		w.pair(8, "0")
+		w.pair(100, "AcDbBlockBegin")
+		w.pair(2, name)
+		w.pair(70, "0")
+		w.num(10, 0)
+		w.num(20, 0)
+		w.num(30, 0)
+		w.pair(3, name)
+		w.pair(1, "")
+		w.object("ENDBLK", records[i], "AcDbEntity")
+		w.pair(8, "0")
+		w.pair(100, "AcDbBlockEnd")
+	}
+	w.pair(0, "ENDSEC")
+
+	w.pair(0, "SECTION")
+	w.pair(2, "ENTITIES")
+	for _, l := range layers {
+		if l.Shapes == nil {
+			continue
+		}
+		name, holes, _, _ := l.layerNames()
+		for _, p := range l.Shapes.P {
+			layer := name
+			if p.Hole {
+				layer = holes
+			}
+			w.object("LWPOLYLINE", records[0], "AcDbEntity")
+			w.pair(8, layer)
+			w.pair(100, "AcDbPolyline")
+			w.pair(90, strconv.Itoa(len(p.PS)))
+			w.pair(70, "1")
+			for _, pt := range p.PS {
+				w.num(10, pt.X)
+				w.num(20, pt.Y)
+			}
+		}
+	}
+	w.pair(0, "ENDSEC")
+
+	// The root dictionary must be the first object.
+	w.pair(0, "SECTION")
+	w.pair(2, "OBJECTS")
+	root := w.object("DICTIONARY", "0", "AcDbDictionary")
+	w.pair(281, "1")
+	group := w.next()
+	w.pair(3, "ACAD_GROUP")
+	w.pair(350, group)
+	w.start("DICTIONARY", group, root, "AcDbDictionary")
+	w.pair(281, "1")
+	w.pair(0, "ENDSEC")
+	w.pair(0, "EOF")
+
+	body := w.b
+	w.b = &bytes.Buffer{}
+	w.pair(0, "SECTION")
+	w.pair(2, "HEADER")
+	w.pair(9, "$ACADVER")
+	w.pair(1, "AC1015")
+	w.pair(9, "$HANDSEED")
+	w.pair(5, w.next())
+	if cfg.Units != 0 {
+		w.pair(9, "$INSUNITS")
+		w.pair(70, strconv.Itoa(cfg.Units))
+	}
+	w.pair(0, "ENDSEC")
+	if _, err := w.b.WriteTo(out); err != nil {
+		return err
+	}
+	_, err := body.WriteTo(out)
+	return err
+}
@@ -0,0 +1,124 @@
+package dxf
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"zappem.net/pub/math/polygon"
+)
+
+func TestWrite(t *testing.T) {
+	var s *polygon.Shapes
+	s = s.Builder(polygon.Point{0, 0}, polygon.Point{10, 0}, polygon.Point{10, 10}, polygon.Point{0, 10})
+	s = s.Builder(polygon.Point{2, 2}, polygon.Point{2, 8}, polygon.Point{8, 8}, polygon.Point{8, 2.5})
+	var b bytes.Buffer
+	if err := Write(&b, &Config{Units: 4}, Layer{Shapes: s}, Layer{Name: "TEXT", Holes: "TEXT", Color: 3}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines)%2 != 0 {
+		t.Fatalf("odd number of lines:\n%s", b.String())
+	}
+	type pair struct {
+		code  int
+		value string
+	}
+	var ps []pair
+	for i := 0; i < len(lines); i += 2 {
+		code, err := strconv.Atoi(strings.TrimSpace(lines[i]))
+		if err != nil {
+			t.Fatalf("bad group code %q at line %d", lines[i], i+1)
+		}
+		ps = append(ps, pair{code, lines[i+1]})
+	}
+
+	var sections, layers []string
+	var seed string
+	handles := make(map[string]string)
+	var owners []string
+	var kind, record, model string
+	polylines := 0
+	for i, p := range ps {
+		switch p.code {
+		case 0:
+			kind = p.value
+		case 2:
+			switch {
+			case i > 0 && ps[i-1].value == "SECTION":
+				sections = append(sections, p.value)
+			case kind == "LAYER":
+				layers = append(layers, p.value+"/"+ps[i+2].value)
+			case kind == "BLOCK_RECORD" && p.value == "*Model_Space":
+				model = record
+			}
+		case 5, 105:
+			if ps[i-1].value == "$HANDSEED" {
+				seed = p.value
+				continue
+			}
+			if handles[p.value] != "" {
+				t.Errorf("handle %s of %s is also used by %s", p.value, kind, handles[p.value])
+			}
+			handles[p.value] = kind
+			record = p.value
+		case 330:
+			owners = append(owners, p.value)
+			if kind == "LWPOLYLINE" {
+				polylines++
+				if p.value != model {
+					t.Errorf("LWPOLYLINE owned by %s, not model space %s", p.value, model)
+				}
+			}
+		}
+	}
+	if got, want := strings.Join(sections, ","), "HEADER,CLASSES,TABLES,BLOCKS,ENTITIES,OBJECTS"; got != want {
+		t.Errorf("got sections %q, want %q", got, want)
+	}
+	if got, want := strings.Join(layers, ","), "0/7,OUTLINE/1,OUTLINE-HOLES/5,TEXT/3"; got != want {
+		t.Errorf("got layers %q, want %q", got, want)
+	}
+	for _, want := range []string{"  9\n$ACADVER\n  1\nAC1015\n", "  9\n$INSUNITS\n 70\n4\n"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("header does not contain %q", want)
+		}
+	}
+	next, err := strconv.ParseInt(seed, 16, 64)
+	if err != nil {
+		t.Fatalf("bad $HANDSEED %q: %v", seed, err)
+	}
+	for h := range handles {
+		if v, _ := strconv.ParseInt(h, 16, 64); v <= 0 || v >= next {
+			t.Errorf("handle %s outside range (0,%s)", h, seed)
+		}
+	}
+	for _, o := range owners {
+		if o != "0" && handles[o] == "" {
+			t.Errorf("owner %s is not an object", o)
+		}
+	}
+	if polylines != 2 {
+		t.Errorf("got %d LWPOLYLINE entities, want 2", polylines)
+	}
+
+	ents, err := Read(&b)
+	if err != nil {
+		t.Fatalf("failed to read drawing: %v", err)
+	}
+	if len(ents) != 2 {
+		t.Fatalf("read %d entities, want 2", len(ents))
+	}
+	for i, layer := range []string{"OUTLINE", "OUTLINE-HOLES"} {
+		e := ents[i]
+		if e.Type != "LWPOLYLINE" || e.Layer != layer || !e.Closed || len(e.Pts) != len(s.P[i].PS) {
+			t.Errorf("[%d] read %#v", i, e)
+			continue
+		}
+		for j, pt := range e.Pts {
+			if !polygon.MatchPoint(pt, s.P[i].PS[j]) {
+				t.Errorf("[%d] point %d got %v want %v", i, j, pt, s.P[i].PS[j])
+			}
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package dxf
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"zappem.net/pub/graphics/polymark"
+	"zappem.net/pub/math/polygon"
+)
+
+// ErrFormat indicates that the DXF input could not be parsed.
+var ErrFormat = errors.New("bad dxf format")
+
+// Entity holds a single line drawing entity read from the ENTITIES
+// section of a DXF file. Angles are in radians.
+type Entity struct {
+	// Type is one of "LINE", "ARC", "CIRCLE", "LWPOLYLINE" or
+	// "SPLINE".
+	Type string
+
+	// Layer is the DXF layer holding the entity.
+	Layer string
+
+	// Pts holds the end points of a LINE, the vertices of a
+	// LWPOLYLINE or the control points of a SPLINE.
+	Pts []polygon.Point
+
+	// Bulges holds the bulge value of each LWPOLYLINE vertex. A
+	// non-zero bulge is the tangent of a quarter of the included
+	// angle of the arc to the next vertex, positive values being
+	// counter-clockwise.
+	Bulges []float64
+
+	// Closed indicates a closed LWPOLYLINE or SPLINE.
+	Closed bool
+
+	// Center and Radius define an ARC or CIRCLE. An ARC runs
+	// counter-clockwise from angle Start to angle End.
+	Center     polygon.Point
+	Radius     float64
+	Start, End float64
+
+	// Degree, Knots and Weights define the B-spline of a SPLINE
+	// with control points Pts. Fit holds any fit points of a SPLINE.
+	Degree  int
+	Knots   []float64
+	Weights []float64
+	Fit     []polygon.Point
+
+	// mirrored indicates an extrusion direction of (0,0,-1).
+	mirrored bool
+
+	// x and fitX hold X coordinates until their Y values are read.
+	x, fitX float64
+}
+
+// pairs reads DXF group code and value pairs.
+type pairs struct {
+	sc   *bufio.Scanner
+	line int
+}
+
+// next returns the next group code and value pair.
+func (p *pairs) next() (code int, value string, err error) {
+	if !p.sc.Scan() {
+		if err = p.sc.Err(); err == nil {
+			err = io.EOF
+		}
+		return
+	}
+	p.line++
+	if code, err = strconv.Atoi(strings.TrimSpace(p.sc.Text())); err != nil {
+		err = fmt.Errorf("%w: bad group code at line %d", ErrFormat, p.line)
+		return
+	}
+	if !p.sc.Scan() {
+		err = fmt.Errorf("%w: missing value at line %d", ErrFormat, p.line)
+		return
+	}
+	p.line++
+	value = strings.TrimSpace(p.sc.Text())
+	return
+}
+
+// float parses a numerical value.
+func (p *pairs) float(value string) (float64, error) {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: bad number %q at line %d", ErrFormat, value, p.line)
+	}
+	return v, nil
+}
+
+// apply applies the group code and value pair to e.
+func (e *Entity) apply(p *pairs, code int, value string) error {
+	if code == 8 {
+		e.Layer = value
+		return nil
+	}
+	switch code {
+	case 10, 20, 11, 21, 40, 41, 42, 50, 51, 230:
+	case 70, 71:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%w: bad integer %q at line %d", ErrFormat, value, p.line)
+		}
+		if code == 70 {
+			e.Closed = n&1 != 0
+		} else {
+			e.Degree = n
+		}
+		return nil
+	default:
+		return nil
+	}
+	v, err := p.float(value)
+	if err != nil {
+		return err
+	}
+	switch code {
+	case 10:
+		e.x = v
+	case 20:
+		pt := polygon.Point{X: e.x, Y: v}
+		switch e.Type {
+		case "ARC", "CIRCLE":
+			e.Center = pt
+		default:
+			e.Pts = append(e.Pts, pt)
+			if e.Type == "LWPOLYLINE" {
+				e.Bulges = append(e.Bulges, 0)
+			}
+		}
+	case 11:
+		e.fitX = v
+	case 21:
+		pt := polygon.Point{X: e.fitX, Y: v}
+		if e.Type == "LINE" {
+			e.Pts = append(e.Pts, pt)
+		} else {
+			e.Fit = append(e.Fit, pt)
+		}
+	case 40:
+		if e.Type == "SPLINE" {
+			e.Knots = append(e.Knots, v)
+		} else {
+			e.Radius = v
+		}
+	case 41:
+		e.Weights = append(e.Weights, v)
+	case 42:
+		if n := len(e.Bulges); n != 0 {
+			e.Bulges[n-1] = v
+		}
+	case 50:
+		e.Start = v * math.Pi / 180
+	case 51:
+		e.End = v * math.Pi / 180
+	case 230:
+		e.mirrored = v < 0
+	}
+	return nil
+}
+
+// mirror converts the coordinates of an entity whose extrusion
+// direction is (0,0,-1) into world coordinates. Only ARC, CIRCLE and
+// LWPOLYLINE entities have coordinates in this object coordinate
+// system. Those of LINE and SPLINE entities are world coordinates.
+func (e *Entity) mirror() {
+	if !e.mirrored {
+		return
+	}
+	switch e.Type {
+	case "ARC", "CIRCLE", "LWPOLYLINE":
+	default:
+		return
+	}
+	for i := range e.Pts {
+		e.Pts[i].X = -e.Pts[i].X
+	}
+	for i := range e.Bulges {
+		e.Bulges[i] = -e.Bulges[i]
+	}
+	e.Center.X = -e.Center.X
+	e.Start, e.End = math.Pi-e.End, math.Pi-e.Start
+}
+
+// Read reads the LINE, ARC, CIRCLE, LWPOLYLINE and SPLINE entities
+// from the ENTITIES section of a DXF file. Other entities are
+// ignored. Only the X and Y coordinates of the entities are used,
+// but ARC, CIRCLE and LWPOLYLINE entities with an extrusion direction
+// of (0,0,-1) are mirrored into world coordinates.
+func Read(r io.Reader) ([]Entity, error) {
+	p := &pairs{sc: bufio.NewScanner(r)}
+	var ents []Entity
+	var e *Entity
+	inEntities, section := false, false
+	for {
+		code, value, err := p.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if code == 0 {
+			if e != nil {
+				e.mirror()
+				ents = append(ents, *e)
+				e = nil
+			}
+			switch value {
+			case "SECTION":
+				section = true
+				continue
+			case "ENDSEC", "EOF":
+				inEntities = false
+			case "LINE", "ARC", "CIRCLE", "LWPOLYLINE", "SPLINE":
+				if inEntities {
+					e = &Entity{Type: value}
+				}
+			}
+			if value == "EOF" {
+				break
+			}
+			continue
+		}
+		if section {
+			section = false
+			inEntities = code == 2 && value == "ENTITIES"
+			continue
+		}
+		if e != nil {
+			if err := e.apply(p, code, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if e != nil {
+		e.mirror()
+		ents = append(ents, *e)
+	}
+	return ents, nil
+}
+
+// bulge appends to pts the points of a straight line approximation of
+// the arc from a to b with the given bulge, omitting a.
+func bulge(pts []polygon.Point, a, b polygon.Point, bulge, scribe float64) []polygon.Point {
+	if bulge == 0 {
+		return append(pts, b)
+	}
+	theta := 4 * math.Atan(bulge)
+	dX, dY := b.X-a.X, b.Y-a.Y
+	chord := math.Hypot(dX, dY)
+	if chord == 0 {
+		return pts
+	}
+	r := chord / (2 * math.Sin(theta/2))
+	// The distance from the middle of the chord to the center.
+	h := r * math.Cos(theta/2)
+	c := polygon.Point{
+		X: (a.X+b.X)/2 - h*dY/chord,
+		Y: (a.Y+b.Y)/2 + h*dX/chord,
+	}
+	start := math.Atan2(a.Y-c.Y, a.X-c.X)
+	n := math.Ceil(math.Abs(theta*r) / scribe)
+	if n < 4 {
+		n = 4
+	}
+	r = math.Abs(r)
+	for i := 1.0; i < n; i++ {
+		ang := start + theta*i/n
+		pts = append(pts, polygon.Point{X: c.X + r*math.Cos(ang), Y: c.Y + r*math.Sin(ang)})
+	}
+	return append(pts, b)
+}
+
+// polyline returns the points of a LWPOLYLINE entity, approximating
+// any arcs with straight segments no longer than scribe.
+func (e *Entity) polyline(scribe float64) []polygon.Point {
+	if len(e.Pts) == 0 {
+		return nil
+	}
+	pts := []polygon.Point{e.Pts[0]}
+	n := len(e.Pts)
+	last := n - 1
+	if e.Closed {
+		last = n
+	}
+	for i := 0; i < last; i++ {
+		pts = bulge(pts, e.Pts[i], e.Pts[(i+1)%n], e.Bulges[i], scribe)
+	}
+	return pts
+}
+
+// spline returns n+1 points sampled uniformly over the parameter
+// range of a (rational) B-spline entity. Splines without a valid
+// knot vector are approximated by their control points, or their fit
+// points if they have no control points.
+func (e *Entity) spline(n int) []polygon.Point {
+	ctrl, p, k := e.Pts, e.Degree, e.Knots
+	if len(ctrl) == 0 {
+		return e.Fit
+	}
+	if p < 1 || len(k) != len(ctrl)+p+1 {
+		return ctrl
+	}
+	weight := func(i int) float64 {
+		if i < len(e.Weights) && e.Weights[i] > 0 {
+			return e.Weights[i]
+		}
+		return 1
+	}
+	u0, u1 := k[p], k[len(ctrl)]
+	d := make([][3]float64, p+1)
+	var pts []polygon.Point
+	for i := 0; i <= n; i++ {
+		u := u0 + (u1-u0)*float64(i)/float64(n)
+		s := p
+		for s < len(ctrl)-1 && k[s+1] <= u {
+			s++
+		}
+		for j := 0; j <= p; j++ {
+			pt, w := ctrl[j+s-p], weight(j+s-p)
+			d[j] = [3]float64{pt.X * w, pt.Y * w, w}
+		}
+		for r := 1; r <= p; r++ {
+			for j := p; j >= r; j-- {
+				den := k[j+1+s-r] - k[j+s-p]
+				alpha := 0.0
+				if den != 0 {
+					alpha = (u - k[j+s-p]) / den
+				}
+				for m := range d[j] {
+					d[j][m] = (1-alpha)*d[j-1][m] + alpha*d[j][m]
+				}
+			}
+		}
+		pts = append(pts, polygon.Point{X: d[p][0] / d[p][2], Y: d[p][1] / d[p][2]})
+	}
+	return pts
+}
+
+// Render appends to s the outlines of lines of the specified width
+// that follow each of the entities, ents. LINE and unclosed
+// LWPOLYLINE and SPLINE entities are rendered with (*polymark.Pen).Line
+// with rounded ends, and closed ones with (*polymark.Pen).Loop. ARC
+// entities are rendered with (*polymark.Pen).Arc, and CIRCLE entities
+// as rings, or with (*polymark.Pen).Circle when they are too small to
+// have a hole. Curves are approximated with straight segments no
+// longer than pen.Scribe.
+func Render(pen *polymark.Pen, s *polygon.Shapes, ents []Entity, width float64) (*polygon.Shapes, error) {
+	round := polymark.Caps{Start: polymark.CapRound, End: polymark.CapRound}
+	for _, e := range ents {
+		var pts []polygon.Point
+		switch e.Type {
+		case "LINE":
+			pts = e.Pts
+		case "LWPOLYLINE":
+			pts = e.polyline(pen.Scribe)
+		case "SPLINE":
+			var l float64
+			for i := 1; i < len(e.Pts); i++ {
+				l += math.Hypot(e.Pts[i].X-e.Pts[i-1].X, e.Pts[i].Y-e.Pts[i-1].Y)
+			}
+			n := int(math.Ceil(l / pen.Scribe))
+			if n < 16 {
+				n = 16
+			}
+			pts = e.spline(n)
+		case "CIRCLE":
+			if e.Radius <= width/2 {
+				s = pen.Circle(s, e.Center, e.Radius+width/2)
+				continue
+			}
+			var err error
+			if s, err = pen.Arc(s, e.Center, e.Radius, 0, 2*math.Pi, width, round); err != nil {
+				return s, err
+			}
+			continue
+		case "ARC":
+			sweep := math.Mod(e.End-e.Start, 2*math.Pi)
+			if sweep <= 0 {
+				sweep += 2 * math.Pi
+			}
+			var err error
+			if s, err = pen.Arc(s, e.Center, e.Radius, e.Start, sweep, width, round); err != nil {
+				return s, err
+			}
+			continue
+		default:
+			continue
+		}
+		if e.Closed {
+			s = pen.Loop(s, pts, width)
+		} else {
+			s = pen.Line(s, pts, width, true, true)
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,130 @@
+package dxf
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"zappem.net/pub/graphics/polymark"
+	"zappem.net/pub/math/polygon"
+)
+
+// drawing holds one of each of the supported entities, and one that
+// is ignored.
+var drawing = strings.Join([]string{
+	"0", "SECTION", "2", "HEADER", "9", "$ACADVER", "1", "AC1015", "0", "ENDSEC",
+	"0", "SECTION", "2", "ENTITIES",
+	"0", "LINE", "8", "CUT", "10", "0.0", "20", "0.0", "30", "0.0", "11", "10.0", "21", "0.0", "31", "0.0",
+	"0", "ARC", "8", "CUT", "10", "0.0", "20", "0.0", "40", "5.0", "50", "0.0", "51", "90.0",
+	"0", "CIRCLE", "8", "CUT", "10", "20.0", "20", "20.0", "40", "3.0",
+	"0", "LWPOLYLINE", "8", "CUT", "90", "2", "70", "0", "10", "0.0", "20", "0.0", "42", "1.0", "10", "10.0", "20", "0.0",
+	"0", "SPLINE", "8", "CUT", "70", "8", "71", "2", "72", "6", "73", "3",
+	"40", "0.0", "40", "0.0", "40", "0.0", "40", "1.0", "40", "1.0", "40", "1.0",
+	"10", "0.0", "20", "0.0", "10", "5.0", "20", "10.0", "10", "10.0", "20", "0.0",
+	"0", "TEXT", "8", "CUT", "1", "ignored",
+	"0", "ENDSEC", "0", "EOF",
+}, "\n") + "\n"
+
+func TestRead(t *testing.T) {
+	ents, err := Read(strings.NewReader(drawing))
+	if err != nil {
+		t.Fatalf("failed to read drawing: %v", err)
+	}
+	var types []string
+	for _, e := range ents {
+		types = append(types, e.Type)
+		if e.Layer != "CUT" {
+			t.Errorf("%s entity on layer %q", e.Type, e.Layer)
+		}
+	}
+	if got, want := strings.Join(types, ","), "LINE,ARC,CIRCLE,LWPOLYLINE,SPLINE"; got != want {
+		t.Fatalf("got entities %q, want %q", got, want)
+	}
+	if e := ents[0]; len(e.Pts) != 2 || !polygon.MatchPoint(e.Pts[1], polygon.Point{10, 0}) {
+		t.Errorf("bad LINE: %v", e.Pts)
+	}
+	if e := ents[1]; e.Radius != 5 || math.Abs(e.End-math.Pi/2) > 1e-9 {
+		t.Errorf("bad ARC: %#v", e)
+	}
+	if e := ents[3]; len(e.Bulges) != 2 || e.Bulges[0] != 1 || e.Closed {
+		t.Errorf("bad LWPOLYLINE: %#v", e)
+	}
+	if e := ents[4]; e.Degree != 2 || len(e.Knots) != 6 || len(e.Pts) != 3 {
+		t.Errorf("bad SPLINE: %#v", e)
+	}
+
+	// A quadratic spline with these knots is a Bezier curve.
+	pts := ents[4].spline(4)
+	if len(pts) != 5 || !polygon.MatchPoint(pts[2], polygon.Point{5, 5}) || !polygon.MatchPoint(pts[4], polygon.Point{10, 0}) {
+		t.Errorf("bad spline points: %v", pts)
+	}
+
+	// A bulge of 1 is a counter-clockwise semicircle, which runs
+	// below the chord from left to right.
+	pts = ents[3].polyline(1)
+	if n := len(pts); !polygon.MatchPoint(pts[n-1], polygon.Point{10, 0}) {
+		t.Errorf("polyline ends at %v", pts[n-1])
+	}
+	for _, pt := range pts {
+		if d := math.Hypot(pt.X-5, pt.Y); math.Abs(d-5) > 1e-9 || pt.Y > 1e-9 {
+			t.Errorf("point %v not on semicircle", pt)
+		}
+	}
+
+	pen := &polymark.Pen{Scribe: 0.1}
+	s, err := Render(pen, nil, ents, 1)
+	if err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+	holes := 0
+	for _, p := range s.P {
+		if p.Hole {
+			holes++
+		}
+	}
+	if holes != 1 {
+		t.Errorf("got %d holes, want 1 for the circle", holes)
+	}
+	ll, tr := s.BB()
+	if math.Abs(ll.X+0.5) > 0.01 || math.Abs(ll.Y+5.5) > 0.01 || math.Abs(tr.X-23.5) > 0.01 || math.Abs(tr.Y-23.5) > 0.01 {
+		t.Errorf("got BB=%v,%v", ll, tr)
+	}
+}
+
+func TestReadExtrusion(t *testing.T) {
+	flipped := strings.Join([]string{
+		"0", "SECTION", "2", "ENTITIES",
+		"0", "LINE", "8", "0", "10", "1.0", "20", "2.0", "11", "3.0", "21", "4.0", "210", "0.0", "220", "0.0", "230", "-1.0",
+		"0", "SPLINE", "8", "0", "210", "0.0", "220", "0.0", "230", "-1.0", "70", "8", "71", "1", "72", "4", "73", "2",
+		"40", "0.0", "40", "0.0", "40", "1.0", "40", "1.0",
+		"10", "1.0", "20", "0.0", "10", "2.0", "20", "0.0",
+		"0", "CIRCLE", "8", "0", "10", "5.0", "20", "1.0", "40", "2.0", "210", "0.0", "220", "0.0", "230", "-1.0",
+		"0", "ARC", "8", "0", "10", "5.0", "20", "1.0", "40", "2.0", "50", "0.0", "51", "90.0", "210", "0.0", "220", "0.0", "230", "-1.0",
+		"0", "LWPOLYLINE", "8", "0", "90", "2", "70", "0", "10", "1.0", "20", "2.0", "10", "3.0", "20", "4.0", "210", "0.0", "220", "0.0", "230", "-1.0",
+		"0", "ENDSEC", "0", "EOF",
+	}, "\n") + "\n"
+	ents, err := Read(strings.NewReader(flipped))
+	if err != nil {
+		t.Fatalf("failed to read drawing: %v", err)
+	}
+	if len(ents) != 5 {
+		t.Fatalf("got %d entities, want 5", len(ents))
+	}
+	// LINE and SPLINE coordinates are world coordinates.
+	if e := ents[0]; !polygon.MatchPoint(e.Pts[0], polygon.Point{1, 2}) || !polygon.MatchPoint(e.Pts[1], polygon.Point{3, 4}) {
+		t.Errorf("flipped LINE was mirrored: %v", e.Pts)
+	}
+	if e := ents[1]; !polygon.MatchPoint(e.Pts[0], polygon.Point{1, 0}) || !polygon.MatchPoint(e.Pts[1], polygon.Point{2, 0}) {
+		t.Errorf("flipped SPLINE was mirrored: %v", e.Pts)
+	}
+	// The others are in the mirrored object coordinate system.
+	if e := ents[2]; !polygon.MatchPoint(e.Center, polygon.Point{-5, 1}) {
+		t.Errorf("flipped CIRCLE center %v, want {-5 1}", e.Center)
+	}
+	if e := ents[3]; !polygon.MatchPoint(e.Center, polygon.Point{-5, 1}) || math.Abs(e.Start-math.Pi/2) > 1e-9 || math.Abs(e.End-math.Pi) > 1e-9 {
+		t.Errorf("flipped ARC got center=%v start=%g end=%g", e.Center, e.Start, e.End)
+	}
+	if e := ents[4]; !polygon.MatchPoint(e.Pts[0], polygon.Point{-1, 2}) || !polygon.MatchPoint(e.Pts[1], polygon.Point{-3, 4}) {
+		t.Errorf("flipped LWPOLYLINE got %v", e.Pts)
+	}
+}
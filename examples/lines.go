@@ -22,7 +22,7 @@ import (
 
 	"zappem.net/pub/graphics/hershey"
 	"zappem.net/pub/graphics/polymark"
-	"zappem.net/pub/graphics/raster"
+	"zappem.net/pub/graphics/polymark/preview"
 	"zappem.net/pub/math/polygon"
 )
 
@@ -94,29 +94,15 @@ func main() {
 	im := image.NewRGBA(image.Rect(0, 0, *width, *height))
 	draw.Draw(im, im.Bounds(), &image.Uniform{color.RGBA{0xff, 0xff, 0xff, 0xff}}, image.ZP, draw.Src)
 
-	rast := raster.NewRasterizer()
-
 	if *fill {
-		var holes []int
-		for i, p := range poly.P {
-			if p.Hole {
-				holes = append(holes, i)
-			}
+		opts := &preview.Options{
+			Weight:     -1,
+			Hatch:      *hatch,
+			HatchAngle: *angle / 180 * math.Pi,
+			Scribe:     *scribe,
 		}
-		for i, p := range poly.P {
-			if p.Hole {
-				continue
-			}
-			lines, err := poly.Hatch(i, *scribe, *hatch, *hatch/2, *angle/180*math.Pi, holes...)
-			if err != nil {
-				log.Fatalf("slice failed: %v", err)
-			}
-			col := color.RGBA{0xb0, 0xa0, 0xf0, 0xff}
-			for _, line := range lines {
-				raster.LineTo(rast, true, line.From.X, line.From.Y, line.To.X, line.To.Y, 1)
-				rast.Render(im, 0, 0, col)
-				rast.Reset()
-			}
+		if err := preview.Render(im, poly, opts); err != nil {
+			log.Fatalf("slice failed: %v", err)
 		}
 	}
 
@@ -139,30 +125,14 @@ func main() {
 			s = tPen.Text(s, x, y, .15, align, font, fmt.Sprint(i))
 		}
 		s.Union()
-		col := color.RGBA{0x0, 0x0, 0x0, 0xff}
-		for _, p := range s.P {
-			from := p.PS[len(p.PS)-1]
-			for _, to := range p.PS {
-				raster.LineTo(rast, true, from.X, from.Y, to.X, to.Y, *weight)
-				from = to
-			}
-			rast.Render(im, 0, 0, col)
-			rast.Reset()
+		black := color.RGBA{0x0, 0x0, 0x0, 0xff}
+		if err := preview.Render(im, s, &preview.Options{Outline: black, Hole: black, Weight: *weight}); err != nil {
+			log.Fatalf("failed to render ids: %v", err)
 		}
 	}
 
-	for _, p := range poly.P {
-		col := color.RGBA{0xff, 0, 0, 0xff}
-		if p.Hole {
-			col = color.RGBA{0, 0, 0xff, 0xff}
-		}
-		from := p.PS[len(p.PS)-1]
-		for _, to := range p.PS {
-			raster.LineTo(rast, true, from.X, from.Y, to.X, to.Y, *weight)
-			from = to
-		}
-		rast.Render(im, 0, 0, col)
-		rast.Reset()
+	if err := preview.Render(im, poly, &preview.Options{Weight: *weight}); err != nil {
+		log.Fatalf("failed to render outlines: %v", err)
 	}
 
 	f, err := os.Create(*dest)
@@ -1,6 +1,6 @@
 // Program spirals generates a selection of spiral polygon shapes.
-// The program can output in polygon.Shapes json format, or as a PNG.
-// To convert to an SVG, see the examples/outline.go tool of the
+// The program can output in polygon.Shapes json format, as a PNG, or
+// as an SVG.
 package main
 
 import (
@@ -15,7 +15,7 @@ import (
 	"os"
 
 	"zappem.net/pub/graphics/polymark"
-	"zappem.net/pub/graphics/raster"
+	"zappem.net/pub/graphics/polymark/preview"
 	"zappem.net/pub/math/polygon"
 )
 
@@ -23,6 +23,8 @@ var (
 	width = flag.Float64("width", 5, "point width of lines")
 	poly  = flag.String("poly", "", "named json output file")
 	img   = flag.String("png", "", "named png output file")
+	svg   = flag.String("svg", "", "named svg output file")
+	hatch = flag.Float64("hatch", 0, "--svg hatch line separation")
 )
 
 func main() {
@@ -64,23 +66,29 @@ func main() {
 		if err := enc.Encode(ps); err != nil {
 			log.Fatalf("failed to json encode %q: %v", *poly, err)
 		}
+	} else if *svg != "" {
+		out, err := os.Create(*svg)
+		if err != nil {
+			log.Fatalf("Unable to generate SVG output %q: %v", *svg, err)
+		}
+		defer out.Close()
+		opts := &polymark.SVGOptions{
+			Colors:  []string{"red"},
+			Outline: 0.5,
+			Hatch:   *hatch,
+			FlipY:   true,
+			Margin:  5,
+			Units:   "mm",
+		}
+		if err := polymark.WriteSVG(out, []*polygon.Shapes{ps}, opts); err != nil {
+			log.Fatalf("failed to write %q: %v", *svg, err)
+		}
 	} else if *img != "" {
 		im := image.NewRGBA(image.Rect(0, 0, 500, 500))
 		draw.Draw(im, im.Bounds(), &image.Uniform{color.RGBA{0xff, 0xff, 0xff, 0xff}}, image.ZP, draw.Src)
 
-		rast := raster.NewRasterizer()
-		for _, p := range ps.P {
-			col := color.RGBA{0xff, 0, 0, 0xff}
-			if p.Hole {
-				col = color.RGBA{0, 0, 0xff, 0xff}
-			}
-			from := p.PS[len(p.PS)-1]
-			for _, to := range p.PS {
-				raster.LineTo(rast, true, from.X, 500-from.Y, to.X, 500-to.Y, 1)
-				from = to
-			}
-			rast.Render(im, 0, 0, col)
-			rast.Reset()
+		if err := preview.Render(im, ps, &preview.Options{FlipY: true}); err != nil {
+			log.Fatalf("failed to render %q: %v", *img, err)
 		}
 
 		f, err := os.Create(*img)
@@ -91,6 +99,6 @@ func main() {
 		png.Encode(f, im)
 		return
 	} else {
-		log.Fatal("supply --poly=<name> or --png=<name> for output")
+		log.Fatal("supply --poly=<name>, --png=<name> or --svg=<name> for output")
 	}
 }
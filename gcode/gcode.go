@@ -0,0 +1,272 @@
+// Package gcode generates G-code machine instructions that trace
+// polygon.Shapes outlines and fill lines, such as those generated by
+// (*polygon.Shapes).Hatch. The output is suitable for GRBL style
+// laser engravers, pen plotters and CNC machines.
+package gcode
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"text/template"
+
+	"zappem.net/pub/graphics/polymark/internal/plot"
+	"zappem.net/pub/math/polygon"
+)
+
+// ErrClosed indicates an attempt to use a closed Encoder.
+var ErrClosed = errors.New("encoder closed")
+
+// ErrPower indicates a laser configuration without a positive Power,
+// which would not mark anything.
+var ErrPower = errors.New("laser power not positive")
+
+// DefaultHeader is the header template used when Config.Header is
+// empty.
+const DefaultHeader = `{{if .Inches}}G20{{else}}G21{{end}}
+G90
+`
+
+// DefaultFooter is the footer template used when Config.Footer is
+// empty.
+const DefaultFooter = `G0 X0 Y0
+M2
+`
+
+// DefaultFeed and DefaultFeedInches are the feed rates, in machine
+// units per minute, used when Config.Feed is not positive.
+const (
+	DefaultFeed       = 1000
+	DefaultFeedInches = 40
+)
+
+// DefaultClearance and DefaultClearanceInches are the heights, in
+// machine units, above Config.CutZ that the tool is raised to when
+// Config.SafeZ is not above CutZ.
+const (
+	DefaultClearance       = 5
+	DefaultClearanceInches = 0.2
+)
+
+// Config holds the machine configuration used to generate G-code.
+type Config struct {
+	// Inches selects G20 (inch) units in the default header,
+	// otherwise G21 (millimeter) units are selected. No
+	// conversion of coordinates is implied by this value, see
+	// Scale.
+	Inches bool
+
+	// Origin is the point in the polygon coordinates that maps to
+	// the machine's origin, and Scale converts the polygon
+	// coordinates to machine units. A Scale of zero implies 1.
+	Origin polygon.Point
+	Scale  float64
+
+	// Feed is the feed rate for marking moves. Moves between
+	// marks are made at the rapid (G0) rate. Values that are not
+	// positive imply DefaultFeed, or DefaultFeedInches if Inches
+	// is true.
+	Feed float64
+
+	// Laser selects laser control of marking: M3 (M4 if Dynamic
+	// is true) with a spindle value of Power turns the laser on,
+	// and M5 turns it off. The Power must be positive, otherwise
+	// writes fail with ErrPower. Without Laser, the tool is raised
+	// to SafeZ with a rapid move, and lowered to CutZ at the
+	// Plunge feed rate. A SafeZ that is not above CutZ implies
+	// DefaultClearance above it, or DefaultClearanceInches if
+	// Inches is true. A Plunge that is not positive implies the
+	// Feed rate. Before the first move, following the header, the
+	// laser is turned off or the tool is raised to SafeZ.
+	Laser   bool
+	Dynamic bool
+	Power   float64
+	SafeZ   float64
+	CutZ    float64
+	Plunge  float64
+
+	// Header and Footer are text/template templates, executed
+	// with the Config as their data, that are written at the
+	// start and end of the output. Empty values imply
+	// DefaultHeader and DefaultFooter.
+	Header, Footer string
+
+	// Precision is the number of decimal places used for
+	// coordinate values. Zero implies 3.
+	Precision int
+}
+
+// Encoder writes G-code to an output stream.
+type Encoder struct {
+	m      machine
+	t      plot.Tracer
+	begun  bool
+	closed bool
+}
+
+// machine writes the G-code that moves and marks with the tool. It
+// implements plot.Marker.
+type machine struct {
+	b     *bufio.Writer
+	cfg   Config
+	xform plot.Transform
+	// feed is set when the next marking move starts a run and
+	// sets the feed rate.
+	feed bool
+}
+
+// NewEncoder returns an Encoder that writes G-code for the machine
+// configuration, cfg, to w. A nil cfg selects the defaults of every
+// Config field: millimeter units, unscaled coordinates and a tool
+// that marks at Z=0 and travels at Z=5.
+func NewEncoder(w io.Writer, cfg *Config) *Encoder {
+	e := &Encoder{m: machine{b: bufio.NewWriter(w)}}
+	c := &e.m.cfg
+	if cfg != nil {
+		*c = *cfg
+	}
+	if c.Scale == 0 {
+		c.Scale = 1
+	}
+	if c.Precision == 0 {
+		c.Precision = 3
+	}
+	if c.Feed <= 0 {
+		c.Feed = DefaultFeed
+		if c.Inches {
+			c.Feed = DefaultFeedInches
+		}
+	}
+	if c.Plunge <= 0 {
+		c.Plunge = c.Feed
+	}
+	if !c.Laser && c.SafeZ <= c.CutZ {
+		c.SafeZ = c.CutZ + DefaultClearance
+		if c.Inches {
+			c.SafeZ = c.CutZ + DefaultClearanceInches
+		}
+	}
+	e.m.xform = plot.Transform{Origin: c.Origin, Scale: c.Scale}
+	e.t.M = &e.m
+	return e
+}
+
+// num formats a value with the configured precision.
+func (m *machine) num(v float64) string {
+	return plot.Num(v, m.cfg.Precision)
+}
+
+// xy formats the machine coordinates of pt.
+func (m *machine) xy(pt polygon.Point) string {
+	pt = m.xform.Apply(pt)
+	return "X" + m.num(pt.X) + " Y" + m.num(pt.Y)
+}
+
+// template executes the named template text.
+func (m *machine) template(name, text string) error {
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		return err
+	}
+	return t.Execute(m.b, &m.cfg)
+}
+
+// begin writes the header, and makes the tool safe to move, if this
+// has not been done yet.
+func (e *Encoder) begin() error {
+	if e.closed {
+		return ErrClosed
+	}
+	if e.begun {
+		return nil
+	}
+	if e.m.cfg.Laser && e.m.cfg.Power <= 0 {
+		return ErrPower
+	}
+	e.begun = true
+	header := e.m.cfg.Header
+	if header == "" {
+		header = DefaultHeader
+	}
+	if err := e.m.template("header", header); err != nil {
+		return err
+	}
+	e.m.Up()
+	return nil
+}
+
+// Move makes a rapid move to pt.
+func (m *machine) Move(pt polygon.Point) {
+	m.b.WriteString("G0 " + m.xy(pt) + "\n")
+}
+
+// Down turns the laser on, or lowers the tool to CutZ.
+func (m *machine) Down() {
+	if m.cfg.Laser {
+		on := "M3"
+		if m.cfg.Dynamic {
+			on = "M4"
+		}
+		m.b.WriteString(on + " S" + m.num(m.cfg.Power) + "\n")
+	} else {
+		m.b.WriteString("G1 Z" + m.num(m.cfg.CutZ) + " F" + m.num(m.cfg.Plunge) + "\n")
+	}
+	m.feed = true
+}
+
+// Draw makes a marking move to pt.
+func (m *machine) Draw(pt polygon.Point) {
+	feed := ""
+	if m.feed {
+		feed = " F" + m.num(m.cfg.Feed)
+		m.feed = false
+	}
+	m.b.WriteString("G1 " + m.xy(pt) + feed + "\n")
+}
+
+// Up turns the laser off, or raises the tool to SafeZ.
+func (m *machine) Up() {
+	if m.cfg.Laser {
+		m.b.WriteString("M5\n")
+	} else {
+		m.b.WriteString("G0 Z" + m.num(m.cfg.SafeZ) + "\n")
+	}
+}
+
+// Outline writes the instructions to trace the closed outline of
+// every polygon in s.
+func (e *Encoder) Outline(s *polygon.Shapes) error {
+	if err := e.begin(); err != nil {
+		return err
+	}
+	e.t.Outline(s)
+	return nil
+}
+
+// Lines writes the instructions to mark each of lines. Consecutive
+// lines that join are marked without stopping.
+func (e *Encoder) Lines(lines []polygon.Line) error {
+	if err := e.begin(); err != nil {
+		return err
+	}
+	e.t.Lines(lines)
+	return nil
+}
+
+// Close stops marking, writes the footer and flushes the output. The
+// Encoder cannot be used after it is closed.
+func (e *Encoder) Close() error {
+	if err := e.begin(); err != nil {
+		return err
+	}
+	e.t.Up()
+	e.closed = true
+	footer := e.m.cfg.Footer
+	if footer == "" {
+		footer = DefaultFooter
+	}
+	if err := e.m.template("footer", footer); err != nil {
+		return err
+	}
+	return e.m.b.Flush()
+}
@@ -0,0 +1,160 @@
+package gcode
+
+import (
+	"bytes"
+	"testing"
+
+	"zappem.net/pub/math/polygon"
+)
+
+func TestEncoder(t *testing.T) {
+	var s *polygon.Shapes
+	s = s.Builder(polygon.Point{1, 1}, polygon.Point{3, 1}, polygon.Point{3, 2.5})
+	lines := []polygon.Line{
+		{From: polygon.Point{0, 0}, To: polygon.Point{1, 0}},
+		{From: polygon.Point{1, 0}, To: polygon.Point{1, 1}},
+		{From: polygon.Point{2, 2}, To: polygon.Point{3, 3}},
+	}
+	ts := []struct {
+		cfg  *Config
+		want string
+	}{
+		{
+			cfg: &Config{Laser: true, Power: 800, Feed: 1200, Origin: polygon.Point{1, 0}},
+			want: `G21
+G90
+M5
+G0 X0 Y1
+M3 S800
+G1 X2 Y1 F1200
+G1 X2 Y2.5
+G1 X0 Y1
+M5
+G0 X-1 Y0
+M3 S800
+G1 X0 Y0 F1200
+G1 X0 Y1
+M5
+G0 X1 Y2
+M3 S800
+G1 X2 Y3 F1200
+M5
+G0 X0 Y0
+M2
+`,
+		},
+		{
+			cfg: &Config{
+				Inches:    true,
+				Scale:     0.5,
+				Feed:      100,
+				SafeZ:     5,
+				CutZ:      -0.25,
+				Plunge:    50,
+				Precision: 2,
+				Header:    "; feed={{.Feed}}\n",
+				Footer:    "M30\n",
+			},
+			want: `; feed=100
+G0 Z5
+G0 X0.5 Y0.5
+G1 Z-0.25 F50
+G1 X1.5 Y0.5 F100
+G1 X1.5 Y1.25
+G1 X0.5 Y0.5
+G0 Z5
+G0 X0 Y0
+G1 Z-0.25 F50
+G1 X0.5 Y0 F100
+G1 X0.5 Y0.5
+G0 Z5
+G0 X1 Y1
+G1 Z-0.25 F50
+G1 X1.5 Y1.5 F100
+G0 Z5
+M30
+`,
+		},
+		{
+			cfg: &Config{SafeZ: 2, CutZ: -1, Precision: 1},
+			want: `G21
+G90
+G0 Z2
+G0 X1 Y1
+G1 Z-1 F1000
+G1 X3 Y1 F1000
+G1 X3 Y2.5
+G1 X1 Y1
+G0 Z2
+G0 X0 Y0
+G1 Z-1 F1000
+G1 X1 Y0 F1000
+G1 X1 Y1
+G0 Z2
+G0 X2 Y2
+G1 Z-1 F1000
+G1 X3 Y3 F1000
+G0 Z2
+G0 X0 Y0
+M2
+`,
+		},
+		{
+			// The tool travels above the work by default.
+			want: `G21
+G90
+G0 Z5
+G0 X1 Y1
+G1 Z0 F1000
+G1 X3 Y1 F1000
+G1 X3 Y2.5
+G1 X1 Y1
+G0 Z5
+G0 X0 Y0
+G1 Z0 F1000
+G1 X1 Y0 F1000
+G1 X1 Y1
+G0 Z5
+G0 X2 Y2
+G1 Z0 F1000
+G1 X3 Y3 F1000
+G0 Z5
+G0 X0 Y0
+M2
+`,
+		},
+	}
+	for i, v := range ts {
+		var b bytes.Buffer
+		e := NewEncoder(&b, v.cfg)
+		if err := e.Outline(s); err != nil {
+			t.Fatalf("[%d] outline failed: %v", i, err)
+		}
+		if err := e.Lines(lines); err != nil {
+			t.Fatalf("[%d] lines failed: %v", i, err)
+		}
+		if err := e.Close(); err != nil {
+			t.Fatalf("[%d] close failed: %v", i, err)
+		}
+		if got := b.String(); got != v.want {
+			t.Errorf("[%d] got:\n%s\nwant:\n%s", i, got, v.want)
+		}
+		if err := e.Lines(lines); err != ErrClosed {
+			t.Errorf("[%d] got err=%v, want %v", i, err, ErrClosed)
+		}
+	}
+}
+
+func TestPower(t *testing.T) {
+	var b bytes.Buffer
+	e := NewEncoder(&b, &Config{Laser: true})
+	if err := e.Lines([]polygon.Line{{To: polygon.Point{1, 0}}}); err != ErrPower {
+		t.Errorf("got err=%v, want %v", err, ErrPower)
+	}
+	if err := e.Close(); err != ErrPower {
+		t.Errorf("close got err=%v, want %v", err, ErrPower)
+	}
+	if b.Len() != 0 {
+		t.Errorf("unexpected output: %q", b.String())
+	}
+}
@@ -0,0 +1,201 @@
+// Package gerber generates Gerber RS-274X (extended Gerber) files
+// that hold polygon.Shapes as filled regions, such as PCB silkscreen
+// legends or copper text.
+package gerber
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"zappem.net/pub/math/polygon"
+)
+
+// ErrOverlap indicates shapes in which a hole crosses the boundary of
+// another polygon, which cannot be rendered with dark and clear
+// regions. Combining the shapes with (*polygon.Shapes).Union()
+// resolves this.
+var ErrOverlap = errors.New("hole overlaps another polygon")
+
+// Config holds the file configuration used to generate Gerber
+// output.
+type Config struct {
+	// Inches selects inch (%MOIN*%) units, otherwise millimeter
+	// (%MOMM*%) units are selected. No conversion of coordinates
+	// is implied by this value, see Scale.
+	Inches bool
+
+	// Origin is the point in the polygon coordinates that maps to
+	// the origin of the Gerber coordinates, and Scale converts the
+	// polygon coordinates to the selected units. A Scale of zero
+	// implies 1.
+	Origin polygon.Point
+	Scale  float64
+
+	// Integer and Decimal are the number of integer and decimal
+	// digits of the coordinate format. Zero values imply 4 (2
+	// for Inches) and 6 respectively.
+	Integer, Decimal int
+
+	// FileFunction, if not empty, is the value of a .FileFunction
+	// file attribute, for example "Legend,Top" or "Copper,L1,Top".
+	FileFunction string
+
+	// Comment, if not empty, is written as G04 comments at the
+	// start of the file, one per line of text.
+	Comment string
+}
+
+// writer holds the state of the Gerber output.
+type writer struct {
+	b     *bufio.Writer
+	cfg   Config
+	scale float64
+	clear bool
+}
+
+// xy formats the Gerber coordinates of pt.
+func (w *writer) xy(pt polygon.Point) string {
+	x := math.Round((pt.X - w.cfg.Origin.X) * w.cfg.Scale * w.scale)
+	y := math.Round((pt.Y - w.cfg.Origin.Y) * w.cfg.Scale * w.scale)
+	return fmt.Sprintf("X%dY%d", int64(x), int64(y))
+}
+
+// polarity selects dark (%LPD*%) or clear (%LPC*%) polarity for the
+// regions that follow.
+func (w *writer) polarity(clear bool) {
+	if clear == w.clear {
+		return
+	}
+	w.clear = clear
+	if clear {
+		w.b.WriteString("%LPC*%\n")
+	} else {
+		w.b.WriteString("%LPD*%\n")
+	}
+}
+
+// region writes the points of a polygon as a G36/G37 region.
+func (w *writer) region(pts []polygon.Point) {
+	w.b.WriteString("G36*\n")
+	fmt.Fprintf(w.b, "%sD02*\n", w.xy(pts[0]))
+	for _, pt := range pts[1:] {
+		fmt.Fprintf(w.b, "%sD01*\n", w.xy(pt))
+	}
+	fmt.Fprintf(w.b, "%sD01*\n", w.xy(pts[0]))
+	w.b.WriteString("G37*\n")
+}
+
+// depth returns the number of the polygons of s that surround p.
+func depth(s *polygon.Shapes, p *polygon.Shape) (n int) {
+	for _, q := range s.P {
+		if q == p {
+			continue
+		}
+		if pInQ, _ := p.Inside(q); pInQ {
+			n++
+		}
+	}
+	return
+}
+
+// crosses reports whether the boundaries of a and b cross.
+func crosses(a, b *polygon.Shape) bool {
+	if a.MinX > b.MaxX || a.MaxX < b.MinX || a.MinY > b.MaxY || a.MaxY < b.MinY {
+		return false
+	}
+	side := func(p, q, r polygon.Point) float64 {
+		return (q.X-p.X)*(r.Y-p.Y) - (q.Y-p.Y)*(r.X-p.X)
+	}
+	for i, a1 := range a.PS {
+		a0 := a.PS[(i+len(a.PS)-1)%len(a.PS)]
+		for j, b1 := range b.PS {
+			b0 := b.PS[(j+len(b.PS)-1)%len(b.PS)]
+			if side(a0, a1, b0)*side(a0, a1, b1) < 0 && side(b0, b1, a0)*side(b0, b1, a1) < 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Write writes a Gerber file holding the polygons of s to out. Each
+// polygon is written as a G36/G37 region, with dark polarity for
+// outer boundaries and clear polarity for holes (Polygon.Hole). A
+// clear region erases everything drawn before it, so the polygons
+// are written in levels from the outside in: the outermost
+// boundaries, then their holes, then the boundaries that sit inside
+// those holes, and so on. This requires that no hole crosses the
+// boundary of another polygon, as is the case for the output of
+// (*polygon.Shapes).Union(). Shapes with such overlaps return
+// ErrOverlap without writing anything. A nil cfg implies a zero
+// Config.
+func Write(out io.Writer, cfg *Config, s *polygon.Shapes) error {
+	var ps []*polygon.Shape
+	if s != nil {
+		for _, p := range s.P {
+			if len(p.PS) >= 3 {
+				ps = append(ps, p)
+			}
+		}
+	}
+	for i, p := range ps {
+		for _, q := range ps[i+1:] {
+			if (p.Hole || q.Hole) && crosses(p, q) {
+				return ErrOverlap
+			}
+		}
+	}
+	depths := make(map[*polygon.Shape]int)
+	for _, p := range ps {
+		depths[p] = depth(s, p)
+	}
+	sort.SliceStable(ps, func(i, j int) bool {
+		return depths[ps[i]] < depths[ps[j]]
+	})
+
+	w := &writer{b: bufio.NewWriter(out)}
+	if cfg != nil {
+		w.cfg = *cfg
+	}
+	if w.cfg.Scale == 0 {
+		w.cfg.Scale = 1
+	}
+	if w.cfg.Integer == 0 {
+		w.cfg.Integer = 4
+		if w.cfg.Inches {
+			w.cfg.Integer = 2
+		}
+	}
+	if w.cfg.Decimal == 0 {
+		w.cfg.Decimal = 6
+	}
+	w.scale = math.Pow10(w.cfg.Decimal)
+
+	if w.cfg.Comment != "" {
+		for _, line := range strings.Split(w.cfg.Comment, "\n") {
+			fmt.Fprintf(w.b, "G04 %s*\n", strings.NewReplacer("*", "", "%", "").Replace(line))
+		}
+	}
+	if w.cfg.FileFunction != "" {
+		fmt.Fprintf(w.b, "%%TF.FileFunction,%s*%%\n", w.cfg.FileFunction)
+	}
+	fmt.Fprintf(w.b, "%%FSLAX%d%dY%d%d*%%\n", w.cfg.Integer, w.cfg.Decimal, w.cfg.Integer, w.cfg.Decimal)
+	if w.cfg.Inches {
+		w.b.WriteString("%MOIN*%\n")
+	} else {
+		w.b.WriteString("%MOMM*%\n")
+	}
+	w.b.WriteString("%LPD*%\nG01*\n")
+
+	for _, p := range ps {
+		w.polarity(p.Hole)
+		w.region(p.PS)
+	}
+	w.b.WriteString("M02*\n")
+	return w.b.Flush()
+}
@@ -0,0 +1,121 @@
+package gerber
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"zappem.net/pub/math/polygon"
+)
+
+func TestWrite(t *testing.T) {
+	var s *polygon.Shapes
+	// A small island listed before the hole of the ring that
+	// surrounds it.
+	s = s.Builder(polygon.Point{4, 4}, polygon.Point{6, 4}, polygon.Point{6, 6}, polygon.Point{4, 6})
+	s = s.Builder(polygon.Point{0, 0}, polygon.Point{10, 0}, polygon.Point{10, 10}, polygon.Point{0, 10})
+	s = s.Builder(polygon.Point{2, 2}, polygon.Point{2, 8}, polygon.Point{8, 8}, polygon.Point{8, 2})
+	ts := []struct {
+		cfg  *Config
+		want string
+	}{
+		{
+			want: `%FSLAX46Y46*%
+%MOMM*%
+%LPD*%
+G01*
+G36*
+X0Y0D02*
+X10000000Y0D01*
+X10000000Y10000000D01*
+X0Y10000000D01*
+X0Y0D01*
+G37*
+%LPC*%
+G36*
+X2000000Y2000000D02*
+X2000000Y8000000D01*
+X8000000Y8000000D01*
+X8000000Y2000000D01*
+X2000000Y2000000D01*
+G37*
+%LPD*%
+G36*
+X4000000Y4000000D02*
+X6000000Y4000000D01*
+X6000000Y6000000D01*
+X4000000Y6000000D01*
+X4000000Y4000000D01*
+G37*
+M02*
+`,
+		},
+		{
+			cfg: &Config{Inches: true, Origin: polygon.Point{2, 2}, Scale: 0.1, Decimal: 3, FileFunction: "Legend,Top", Comment: "label*"},
+			want: `G04 label*
+%TF.FileFunction,Legend,Top*%
+%FSLAX23Y23*%
+%MOIN*%
+%LPD*%
+G01*
+G36*
+X-200Y-200D02*
+X800Y-200D01*
+X800Y800D01*
+X-200Y800D01*
+X-200Y-200D01*
+G37*
+%LPC*%
+G36*
+X0Y0D02*
+X0Y600D01*
+X600Y600D01*
+X600Y0D01*
+X0Y0D01*
+G37*
+%LPD*%
+G36*
+X200Y200D02*
+X400Y200D01*
+X400Y400D01*
+X200Y400D01*
+X200Y200D01*
+G37*
+M02*
+`,
+		},
+	}
+	for i, v := range ts {
+		var b bytes.Buffer
+		if err := Write(&b, v.cfg, s); err != nil {
+			t.Fatalf("[%d] write failed: %v", i, err)
+		}
+		if got := b.String(); got != v.want {
+			t.Errorf("[%d] got:\n%s\nwant:\n%s", i, got, v.want)
+		}
+	}
+}
+
+func TestOverlap(t *testing.T) {
+	var ring *polygon.Shapes
+	ring = ring.Builder(polygon.Point{0, 0}, polygon.Point{10, 0}, polygon.Point{10, 10}, polygon.Point{0, 10})
+	ring = ring.Builder(polygon.Point{2, 2}, polygon.Point{2, 8}, polygon.Point{8, 8}, polygon.Point{8, 2})
+
+	// Overlapping boundaries are all dark, so need no union.
+	s := ring.Duplicate()
+	s = s.Builder(polygon.Point{9, 9}, polygon.Point{12, 9}, polygon.Point{12, 12}, polygon.Point{9, 12})
+	var b bytes.Buffer
+	if err := Write(&b, nil, s); err != nil {
+		t.Fatalf("overlapping boundaries failed: %v", err)
+	}
+	if got := strings.Count(b.String(), "G36*"); got != 3 {
+		t.Errorf("got %d regions, want 3", got)
+	}
+
+	// A boundary that crosses the hole would be partly erased.
+	s = ring.Duplicate()
+	s = s.Builder(polygon.Point{1, 4}, polygon.Point{5, 4}, polygon.Point{5, 6}, polygon.Point{1, 6})
+	if err := Write(&bytes.Buffer{}, nil, s); err != ErrOverlap {
+		t.Errorf("got err=%v, want %v", err, ErrOverlap)
+	}
+}
@@ -6,8 +6,5 @@ toolchain go1.24.10
 
 require (
 	zappem.net/pub/graphics/hershey v0.6.0
-	zappem.net/pub/graphics/raster v0.7.0
 	zappem.net/pub/math/polygon v0.9.19
 )
-
-require golang.org/x/image v0.33.0 // indirect
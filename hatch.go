@@ -0,0 +1,34 @@
+package polymark
+
+import (
+	"zappem.net/pub/math/polygon"
+)
+
+// Hatch returns the lines that fill all of the non-hole polygons of
+// s with parallel lines separated by sep. The lines are at an angle,
+// theta, counter-clockwise from the horizontal axis. They come as
+// close to the polygon outlines as scribe/2, and every hole of s is
+// used to shorten them.
+func Hatch(s *polygon.Shapes, scribe, sep, theta float64) ([]polygon.Line, error) {
+	if s == nil {
+		return nil, nil
+	}
+	var holes []int
+	for i, p := range s.P {
+		if p.Hole {
+			holes = append(holes, i)
+		}
+	}
+	var lines []polygon.Line
+	for i, p := range s.P {
+		if p.Hole {
+			continue
+		}
+		ls, err := s.Hatch(i, scribe, sep, sep/2, theta, holes...)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, ls...)
+	}
+	return lines, nil
+}
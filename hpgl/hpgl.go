@@ -0,0 +1,190 @@
+// Package hpgl generates HPGL (Hewlett-Packard Graphics Language)
+// instructions for pen plotters and vinyl cutters that trace
+// polygon.Shapes outlines and fill lines, such as those generated by
+// (*polygon.Shapes).Hatch.
+//
+// Each run of connected lines is drawn with a single PD (pen down)
+// instruction listing its points, and the pen is raised with PU (pen
+// up) to travel between runs.
+package hpgl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"zappem.net/pub/graphics/polymark/internal/plot"
+	"zappem.net/pub/math/polygon"
+)
+
+// ErrClosed indicates an attempt to use a closed Encoder.
+var ErrClosed = errors.New("encoder closed")
+
+// DefaultUnitsPerMM is the conventional HPGL plotter unit resolution
+// of 0.025 mm.
+const DefaultUnitsPerMM = 40
+
+// Config holds the plotter configuration used to generate HPGL.
+type Config struct {
+	// Origin is the point, in millimeters, of the polygon
+	// coordinates that is drawn at the plotter's origin.
+	Origin polygon.Point
+
+	// UnitsPerMM is the number of plotter units per millimeter.
+	// Plotter coordinates are integers, so the polygon points are
+	// rounded to the nearest plotter unit. Zero implies
+	// DefaultUnitsPerMM.
+	UnitsPerMM float64
+
+	// Pen is the pen selected by the initialization instructions.
+	// Zero implies pen 1.
+	Pen int
+
+	// Velocity, if non-zero, is the pen speed in cm/s set with a
+	// VS instruction.
+	Velocity float64
+}
+
+// Encoder writes HPGL to an output stream.
+type Encoder struct {
+	cfg    Config
+	p      plotter
+	t      plot.Tracer
+	begun  bool
+	closed bool
+	pen    int
+}
+
+// plotter writes the HPGL that moves and draws with the pen. It
+// implements plot.Marker.
+type plotter struct {
+	b     *bufio.Writer
+	xform plot.Transform
+	// first is set when the next point starts a PD instruction.
+	first bool
+}
+
+// NewEncoder returns an Encoder that writes HPGL for the plotter
+// configuration, cfg, to w. A nil cfg draws with pen 1 at the
+// default plotter resolution, with the polygon origin at the
+// plotter's origin.
+func NewEncoder(w io.Writer, cfg *Config) *Encoder {
+	e := &Encoder{}
+	if cfg != nil {
+		e.cfg = *cfg
+	}
+	if e.cfg.UnitsPerMM == 0 {
+		e.cfg.UnitsPerMM = DefaultUnitsPerMM
+	}
+	if e.cfg.Pen == 0 {
+		e.cfg.Pen = 1
+	}
+	e.p = plotter{
+		b:     bufio.NewWriter(w),
+		xform: plot.Transform{Origin: e.cfg.Origin, Scale: e.cfg.UnitsPerMM},
+	}
+	e.t.M = &e.p
+	return e
+}
+
+// xy formats the plotter coordinates of pt.
+func (p *plotter) xy(pt polygon.Point) string {
+	pt = p.xform.Apply(pt)
+	return fmt.Sprintf("%d,%d", int(math.Round(pt.X)), int(math.Round(pt.Y)))
+}
+
+// Move raises the pen and moves it to pt.
+func (p *plotter) Move(pt polygon.Point) {
+	fmt.Fprintf(p.b, "PU%s;\n", p.xy(pt))
+}
+
+// Down starts a PD instruction. The pen is lowered when its first
+// point is drawn.
+func (p *plotter) Down() {
+	p.b.WriteString("PD")
+	p.first = true
+}
+
+// Draw adds pt to the PD instruction.
+func (p *plotter) Draw(pt polygon.Point) {
+	if !p.first {
+		p.b.WriteString(",")
+	}
+	p.first = false
+	p.b.WriteString(p.xy(pt))
+}
+
+// Up terminates the PD instruction. The pen stays down until the
+// next PU.
+func (p *plotter) Up() {
+	p.b.WriteString(";\n")
+}
+
+// begin writes the IN (initialize), VS (velocity) and SP (select
+// pen) instructions, if they have not been written yet.
+func (e *Encoder) begin() error {
+	if e.closed {
+		return ErrClosed
+	}
+	if e.begun {
+		return nil
+	}
+	e.begun = true
+	e.p.b.WriteString("IN;\n")
+	if e.cfg.Velocity != 0 {
+		fmt.Fprintf(e.p.b, "VS%s;\n", plot.Num(e.cfg.Velocity, 2))
+	}
+	e.pen = e.cfg.Pen
+	fmt.Fprintf(e.p.b, "SP%d;\n", e.pen)
+	return nil
+}
+
+// Pen selects pen n for subsequent drawing, ending any PD
+// instruction in progress. Pens are typically numbered from 1, and
+// pen 0 returns the pen to its stall.
+func (e *Encoder) Pen(n int) error {
+	if err := e.begin(); err != nil {
+		return err
+	}
+	if n != e.pen {
+		e.t.Up()
+		e.pen = n
+		fmt.Fprintf(e.p.b, "SP%d;\n", n)
+	}
+	return nil
+}
+
+// Outline writes the instructions to draw the closed outline of
+// every polygon in s, each as a single PD instruction.
+func (e *Encoder) Outline(s *polygon.Shapes) error {
+	if err := e.begin(); err != nil {
+		return err
+	}
+	e.t.Outline(s)
+	return nil
+}
+
+// Lines writes the instructions to draw each of lines. Consecutive
+// lines that join extend the same PD instruction, and the pen is
+// raised to travel to lines that do not.
+func (e *Encoder) Lines(lines []polygon.Line) error {
+	if err := e.begin(); err != nil {
+		return err
+	}
+	e.t.Lines(lines)
+	return nil
+}
+
+// Close raises the pen, returns it to its stall and flushes the
+// output. The Encoder cannot be used after it is closed.
+func (e *Encoder) Close() error {
+	if err := e.begin(); err != nil {
+		return err
+	}
+	e.t.Up()
+	e.p.b.WriteString("PU;\nSP0;\n")
+	e.closed = true
+	return e.p.b.Flush()
+}
@@ -0,0 +1,87 @@
+package hpgl
+
+import (
+	"bytes"
+	"testing"
+
+	"zappem.net/pub/math/polygon"
+)
+
+func TestUnits(t *testing.T) {
+	ts := []struct {
+		cfg  *Config
+		pt   polygon.Point
+		want string
+	}{
+		{nil, polygon.Point{1, 2}, "PU0,0;\nPD40,80;\n"},
+		{nil, polygon.Point{0.0125, -0.0124}, "PU0,0;\nPD1,0;\n"},
+		{&Config{UnitsPerMM: 10}, polygon.Point{1.26, 0.74}, "PU0,0;\nPD13,7;\n"},
+		{&Config{Origin: polygon.Point{1, 1}}, polygon.Point{1, 2}, "PU-40,-40;\nPD0,40;\n"},
+	}
+	for i, v := range ts {
+		var b bytes.Buffer
+		e := NewEncoder(&b, v.cfg)
+		if err := e.Lines([]polygon.Line{{To: v.pt}}); err != nil {
+			t.Fatalf("[%d] lines failed: %v", i, err)
+		}
+		if err := e.Close(); err != nil {
+			t.Fatalf("[%d] close failed: %v", i, err)
+		}
+		want := "IN;\nSP1;\n" + v.want + "PU;\nSP0;\n"
+		if got := b.String(); got != want {
+			t.Errorf("[%d] got:\n%s\nwant:\n%s", i, got, want)
+		}
+	}
+}
+
+func TestPenState(t *testing.T) {
+	var b bytes.Buffer
+	e := NewEncoder(&b, &Config{UnitsPerMM: 1, Pen: 2, Velocity: 12.5})
+	var s *polygon.Shapes
+	s = s.Builder(polygon.Point{0, 0}, polygon.Point{10, 0}, polygon.Point{10, 10}, polygon.Point{0, 10})
+	if err := e.Outline(s); err != nil {
+		t.Fatalf("outline failed: %v", err)
+	}
+	// The first line continues from the end of the outline, and
+	// the last starts a new run.
+	if err := e.Lines([]polygon.Line{
+		{From: polygon.Point{0, 0}, To: polygon.Point{5, 5}},
+		{From: polygon.Point{5, 5}, To: polygon.Point{5, 0}},
+		{From: polygon.Point{7, 7}, To: polygon.Point{8, 8}},
+	}); err != nil {
+		t.Fatalf("lines failed: %v", err)
+	}
+	// Changing pens ends the PD instruction, so the next line
+	// starts with the pen up, even though it joins the last.
+	if err := e.Pen(3); err != nil {
+		t.Fatalf("pen failed: %v", err)
+	}
+	if err := e.Pen(3); err != nil {
+		t.Fatalf("repeated pen failed: %v", err)
+	}
+	if err := e.Lines([]polygon.Line{{From: polygon.Point{8, 8}, To: polygon.Point{9, 8}}}); err != nil {
+		t.Fatalf("lines failed: %v", err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	want := `IN;
+VS12.5;
+SP2;
+PU0,0;
+PD10,0,10,10,0,10,0,0,5,5,5,0;
+PU7,7;
+PD8,8;
+SP3;
+PU8,8;
+PD9,8;
+PU;
+SP0;
+`
+	if got := b.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if err := e.Pen(1); err != ErrClosed {
+		t.Errorf("got err=%v, want %v", err, ErrClosed)
+	}
+}
@@ -0,0 +1,129 @@
+// Package plot holds the number formatting, coordinate transform and
+// path tracing shared by the polymark output encoders.
+package plot
+
+import (
+	"strconv"
+	"strings"
+
+	"zappem.net/pub/math/polygon"
+)
+
+// Num formats v with at most prec decimal places, omitting trailing
+// zeros and the sign of a zero value.
+func Num(v float64, prec int) string {
+	n := strconv.FormatFloat(v, 'f', prec, 64)
+	if strings.Contains(n, ".") {
+		n = strings.TrimRight(strings.TrimRight(n, "0"), ".")
+	}
+	if n == "-0" {
+		n = "0"
+	}
+	return n
+}
+
+// Points returns the number of points, the 1/72 inch unit of PDF
+// and PostScript, per inch, or per millimeter if inches is false.
+func Points(inches bool) float64 {
+	if inches {
+		return 72
+	}
+	return 72 / 25.4
+}
+
+// PageSize returns the dimensions of the default printed page: an A4
+// page in millimeters, or a US Letter page in inches.
+func PageSize(inches bool) (width, height float64) {
+	if inches {
+		return 8.5, 11
+	}
+	return 210, 297
+}
+
+// Transform maps polygon coordinates to output coordinates. The
+// point Origin maps to the output origin, and distances are
+// multiplied by Scale.
+type Transform struct {
+	Origin polygon.Point
+	Scale  float64
+}
+
+// Apply returns the output coordinates of pt.
+func (t Transform) Apply(pt polygon.Point) polygon.Point {
+	return polygon.Point{X: (pt.X - t.Origin.X) * t.Scale, Y: (pt.Y - t.Origin.Y) * t.Scale}
+}
+
+// Marker is implemented by encoders that mark lines with a tool,
+// such as a pen or a laser. A Tracer calls Down before the first
+// Draw of each run of connected lines, and Up after the last.
+type Marker interface {
+	// Move moves the tool to pt without marking.
+	Move(pt polygon.Point)
+	// Down starts marking at the current location.
+	Down()
+	// Draw marks a line from the current location to pt.
+	Draw(pt polygon.Point)
+	// Up stops marking.
+	Up()
+}
+
+// Tracer traces outlines and lines with a Marker, keeping track of
+// the location of the tool and whether it is marking.
+type Tracer struct {
+	M    Marker
+	down bool
+	at   polygon.Point
+}
+
+// Up stops any marking in progress.
+func (t *Tracer) Up() {
+	if t.down {
+		t.down = false
+		t.M.Up()
+	}
+}
+
+// moveTo moves to pt without marking.
+func (t *Tracer) moveTo(pt polygon.Point) {
+	t.Up()
+	t.M.Move(pt)
+	t.at = pt
+}
+
+// lineTo marks a line from the current location to pt.
+func (t *Tracer) lineTo(pt polygon.Point) {
+	if !t.down {
+		t.down = true
+		t.M.Down()
+	}
+	t.M.Draw(pt)
+	t.at = pt
+}
+
+// Outline traces the closed outline of every polygon in s.
+func (t *Tracer) Outline(s *polygon.Shapes) {
+	if s == nil {
+		return
+	}
+	for _, p := range s.P {
+		if len(p.PS) == 0 {
+			continue
+		}
+		t.moveTo(p.PS[0])
+		for _, pt := range p.PS[1:] {
+			t.lineTo(pt)
+		}
+		t.lineTo(p.PS[0])
+	}
+}
+
+// Lines traces each of lines. Consecutive lines that join are
+// marked without stopping.
+func (t *Tracer) Lines(lines []polygon.Line) {
+	for _, line := range lines {
+		if !t.down || !polygon.MatchPoint(t.at, line.From) {
+			t.moveTo(line.From)
+		}
+		t.lineTo(line.To)
+	}
+}
@@ -0,0 +1,69 @@
+package plot
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"zappem.net/pub/math/polygon"
+)
+
+func TestNum(t *testing.T) {
+	ts := []struct {
+		v    float64
+		prec int
+		want string
+	}{
+		{1.5, 3, "1.5"},
+		{2, 3, "2"},
+		{-0.0001, 3, "0"},
+		{1234.56789, 2, "1234.57"},
+		{10, 0, "10"},
+	}
+	for i, v := range ts {
+		if got := Num(v.v, v.prec); got != v.want {
+			t.Errorf("[%d] Num(%g, %d) got=%q want=%q", i, v.v, v.prec, got, v.want)
+		}
+	}
+}
+
+func TestPage(t *testing.T) {
+	if w, h := PageSize(false); w*Points(false) > 596 || h*Points(false) < 841 {
+		t.Errorf("A4 page is %gx%g points", w*Points(false), h*Points(false))
+	}
+	if w, h := PageSize(true); w*Points(true) != 612 || h*Points(true) != 792 {
+		t.Errorf("Letter page is %gx%g points", w*Points(true), h*Points(true))
+	}
+}
+
+// log records the calls made to a Marker.
+type log struct {
+	strings.Builder
+}
+
+func (l *log) Move(pt polygon.Point) { fmt.Fprintf(l, "M%g,%g ", pt.X, pt.Y) }
+func (l *log) Down()                 { l.WriteString("D ") }
+func (l *log) Draw(pt polygon.Point) { fmt.Fprintf(l, "L%g,%g ", pt.X, pt.Y) }
+func (l *log) Up()                   { l.WriteString("U ") }
+
+func TestTracer(t *testing.T) {
+	var s *polygon.Shapes
+	s = s.Builder(polygon.Point{1, 1}, polygon.Point{3, 1}, polygon.Point{3, 2})
+	l := &log{}
+	tr := &Tracer{M: l}
+	tr.Outline(s)
+	tr.Lines([]polygon.Line{
+		{From: polygon.Point{1, 1}, To: polygon.Point{0, 0}},
+		{From: polygon.Point{0, 0}, To: polygon.Point{1, 0}},
+		{From: polygon.Point{2, 2}, To: polygon.Point{3, 3}},
+	})
+	tr.Up()
+	tr.Up()
+	want := "M1,1 D L3,1 L3,2 L1,1 L0,0 L1,0 U M2,2 D L3,3 U "
+	if got := l.String(); got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+	if got := (Transform{Origin: polygon.Point{1, 2}, Scale: 2}).Apply(polygon.Point{2, 1}); got != (polygon.Point{2, -2}) {
+		t.Errorf("transform got %v", got)
+	}
+}
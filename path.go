@@ -0,0 +1,404 @@
+package polymark
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"zappem.net/pub/math/polygon"
+)
+
+// ErrBadPath indicates that SVG path data could not be parsed.
+var ErrBadPath = errors.New("bad svg path")
+
+// Path holds the points of a straight line approximation of a single
+// subpath of some SVG path data.
+type Path struct {
+	// Pts holds the consecutive points of the subpath.
+	Pts []polygon.Point
+
+	// Closed indicates the subpath was closed with a Z or z
+	// command.
+	Closed bool
+}
+
+// pathScanner tokenizes SVG path data.
+type pathScanner struct {
+	d string
+	i int
+}
+
+// skip advances past any whitespace and comma separators.
+func (sc *pathScanner) skip() {
+	for sc.i < len(sc.d) {
+		switch sc.d[sc.i] {
+		case ' ', '\t', '\n', '\r', '\f', ',':
+			sc.i++
+		default:
+			return
+		}
+	}
+}
+
+// done indicates all of the path data has been consumed.
+func (sc *pathScanner) done() bool {
+	sc.skip()
+	return sc.i == len(sc.d)
+}
+
+// more indicates that the next token is a number.
+func (sc *pathScanner) more() bool {
+	if sc.done() {
+		return false
+	}
+	switch c := sc.d[sc.i]; {
+	case c >= '0' && c <= '9', c == '.', c == '-', c == '+':
+		return true
+	}
+	return false
+}
+
+// fail returns an error describing a failure at the current
+// position.
+func (sc *pathScanner) fail(what string) error {
+	return fmt.Errorf("%w: %s at offset %d of %q", ErrBadPath, what, sc.i, sc.d)
+}
+
+// number parses the next number.
+func (sc *pathScanner) number() (float64, error) {
+	if !sc.more() {
+		return 0, sc.fail("expected number")
+	}
+	j := sc.i
+	if c := sc.d[j]; c == '-' || c == '+' {
+		j++
+	}
+	digits := func() (n int) {
+		for ; j < len(sc.d) && sc.d[j] >= '0' && sc.d[j] <= '9'; j++ {
+			n++
+		}
+		return
+	}
+	n := digits()
+	if j < len(sc.d) && sc.d[j] == '.' {
+		j++
+		n += digits()
+	}
+	if n == 0 {
+		return 0, sc.fail("expected number")
+	}
+	if j < len(sc.d) && (sc.d[j] == 'e' || sc.d[j] == 'E') {
+		k := j + 1
+		if k < len(sc.d) && (sc.d[k] == '-' || sc.d[k] == '+') {
+			k++
+		}
+		if k < len(sc.d) && sc.d[k] >= '0' && sc.d[k] <= '9' {
+			j = k
+			digits()
+		}
+	}
+	v, err := strconv.ParseFloat(sc.d[sc.i:j], 64)
+	if err != nil {
+		return 0, sc.fail("invalid number")
+	}
+	sc.i = j
+	return v, nil
+}
+
+// flag parses the next arc flag, which may not be separated from the
+// token that follows it.
+func (sc *pathScanner) flag() (bool, error) {
+	sc.skip()
+	if sc.i < len(sc.d) {
+		switch sc.d[sc.i] {
+		case '0':
+			sc.i++
+			return false, nil
+		case '1':
+			sc.i++
+			return true, nil
+		}
+	}
+	return false, sc.fail("expected flag")
+}
+
+// numbers parses the next len(vs) numbers into vs.
+func (sc *pathScanner) numbers(vs ...*float64) (err error) {
+	for _, v := range vs {
+		if *v, err = sc.number(); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// reflect returns the reflection of pt through c.
+func reflect(pt, c polygon.Point) polygon.Point {
+	return polygon.Point{X: 2*c.X - pt.X, Y: 2*c.Y - pt.Y}
+}
+
+// vectorAngle returns the signed angle from u to v.
+func vectorAngle(u, v polygon.Point) float64 {
+	return math.Atan2(u.X*v.Y-u.Y*v.X, u.Dot(v))
+}
+
+// svgArc appends to pts the points of an SVG elliptical arc from
+// pts[len(pts)-1] to to. The conversion to a center parameterized
+// arc follows the SVG 1.1 implementation notes.
+func (pen *Pen) svgArc(pts []polygon.Point, rx, ry, phi float64, large, sweep bool, to polygon.Point) []polygon.Point {
+	from := pts[len(pts)-1]
+	if polygon.MatchPoint(from, to) {
+		return pts
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		return append(pts, to)
+	}
+	sP, cP := math.Sincos(phi)
+	hX, hY := (from.X-to.X)/2, (from.Y-to.Y)/2
+	x1, y1 := cP*hX+sP*hY, -sP*hX+cP*hY
+	if l := x1*x1/(rx*rx) + y1*y1/(ry*ry); l > 1 {
+		rx, ry = rx*math.Sqrt(l), ry*math.Sqrt(l)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	cX, cY := coef*rx*y1/ry, -coef*ry*x1/rx
+	c := polygon.Point{
+		X: cP*cX - sP*cY + (from.X+to.X)/2,
+		Y: sP*cX + cP*cY + (from.Y+to.Y)/2,
+	}
+	u := polygon.Point{X: (x1 - cX) / rx, Y: (y1 - cY) / ry}
+	v := polygon.Point{X: (-x1 - cX) / rx, Y: (-y1 - cY) / ry}
+	theta := vectorAngle(polygon.Point{X: 1}, u)
+	delta := vectorAngle(u, v)
+	if !sweep && delta > 0 {
+		delta -= twoPi
+	} else if sweep && delta < 0 {
+		delta += twoPi
+	}
+	arc := pen.ellipse(c, rx, ry, phi, theta, delta)
+	pts = append(pts, arc[1:len(arc)-1]...)
+	return append(pts, to)
+}
+
+// ParsePath parses SVG path data, d, the value of the "d" attribute
+// of an SVG <path> element, into a series of subpaths. All of the
+// absolute and relative path commands are supported. Curves are
+// approximated by straight segments that deviate from them by no
+// more than pen.Scribe/4. The coordinates are used as is, so the
+// Y axis points in the opposite direction to that of an SVG image.
+func (pen *Pen) ParsePath(d string) ([]Path, error) {
+	sc := &pathScanner{d: d}
+	var paths []Path
+	var path *Path
+	var cur, start, ctrl polygon.Point
+	var cmd, last byte
+	for !sc.done() {
+		if c := sc.d[sc.i]; (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+			cmd = c
+			sc.i++
+		} else if cmd == 0 || cmd == 'Z' || cmd == 'z' {
+			return nil, sc.fail("expected command")
+		}
+		rel := cmd >= 'a'
+		at := func(x, y float64) polygon.Point {
+			if rel {
+				return polygon.Point{X: cur.X + x, Y: cur.Y + y}
+			}
+			return polygon.Point{X: x, Y: y}
+		}
+		if path == nil && cmd != 'M' && cmd != 'm' {
+			if cmd != 'Z' && cmd != 'z' && len(paths) == 0 {
+				return nil, sc.fail("path must start with a moveto")
+			}
+			paths = append(paths, Path{Pts: []polygon.Point{cur}})
+			path = &paths[len(paths)-1]
+		}
+		var x, y, x1, y1, x2, y2 float64
+		var err error
+		switch cmd {
+		case 'M', 'm':
+			if err = sc.numbers(&x, &y); err != nil {
+				return nil, err
+			}
+			cur = at(x, y)
+			start = cur
+			paths = append(paths, Path{Pts: []polygon.Point{cur}})
+			path = &paths[len(paths)-1]
+			// Subsequent coordinate pairs are implicit lineto
+			// commands.
+			cmd = 'L' + cmd - 'M'
+		case 'L', 'l':
+			if err = sc.numbers(&x, &y); err != nil {
+				return nil, err
+			}
+			cur = at(x, y)
+			path.Pts = append(path.Pts, cur)
+		case 'H', 'h':
+			if err = sc.numbers(&x); err != nil {
+				return nil, err
+			}
+			if rel {
+				x += cur.X
+			}
+			cur = polygon.Point{X: x, Y: cur.Y}
+			path.Pts = append(path.Pts, cur)
+		case 'V', 'v':
+			if err = sc.numbers(&y); err != nil {
+				return nil, err
+			}
+			if rel {
+				y += cur.Y
+			}
+			cur = polygon.Point{X: cur.X, Y: y}
+			path.Pts = append(path.Pts, cur)
+		case 'C', 'c', 'S', 's':
+			var p1 polygon.Point
+			if cmd == 'C' || cmd == 'c' {
+				if err = sc.numbers(&x1, &y1); err != nil {
+					return nil, err
+				}
+				p1 = at(x1, y1)
+			} else if p1 = cur; last == 'C' || last == 'S' {
+				p1 = reflect(ctrl, cur)
+			}
+			if err = sc.numbers(&x2, &y2, &x, &y); err != nil {
+				return nil, err
+			}
+			ctrl = at(x2, y2)
+			to := at(x, y)
+			path.Pts = pen.cubic(path.Pts, cur, p1, ctrl, to, 0)
+			cur = to
+		case 'Q', 'q', 'T', 't':
+			if cmd == 'Q' || cmd == 'q' {
+				if err = sc.numbers(&x1, &y1); err != nil {
+					return nil, err
+				}
+				ctrl = at(x1, y1)
+			} else if last == 'Q' || last == 'T' {
+				ctrl = reflect(ctrl, cur)
+			} else {
+				ctrl = cur
+			}
+			if err = sc.numbers(&x, &y); err != nil {
+				return nil, err
+			}
+			to := at(x, y)
+			path.Pts = pen.quad(path.Pts, cur, ctrl, to)
+			cur = to
+		case 'A', 'a':
+			var rx, ry, phi float64
+			var large, sweep bool
+			if err = sc.numbers(&rx, &ry, &phi); err != nil {
+				return nil, err
+			}
+			if large, err = sc.flag(); err != nil {
+				return nil, err
+			}
+			if sweep, err = sc.flag(); err != nil {
+				return nil, err
+			}
+			if err = sc.numbers(&x, &y); err != nil {
+				return nil, err
+			}
+			to := at(x, y)
+			path.Pts = pen.svgArc(path.Pts, rx, ry, phi*math.Pi/180, large, sweep, to)
+			cur = to
+		case 'Z', 'z':
+			path.Closed = true
+			path = nil
+			cur = start
+		default:
+			return nil, sc.fail(fmt.Sprintf("unsupported command %q", cmd))
+		}
+		// Record the command type for smooth curve
+		// reflections.
+		last = cmd &^ 0x20
+	}
+	return paths, nil
+}
+
+// winding returns the winding number of the polygon pts around pt.
+func winding(pts []polygon.Point, pt polygon.Point) (w int) {
+	for i, b := range pts {
+		a := pts[(i+len(pts)-1)%len(pts)]
+		cross := (b.X-a.X)*(pt.Y-a.Y) - (pt.X-a.X)*(b.Y-a.Y)
+		if a.Y <= pt.Y && b.Y > pt.Y && cross > 0 {
+			w++
+		} else if a.Y > pt.Y && b.Y <= pt.Y && cross < 0 {
+			w--
+		}
+	}
+	return
+}
+
+// FillPath appends to s a polygon for each subpath of the SVG path
+// data, d, as parsed by (*Pen).ParsePath. Every subpath is treated as
+// closed, and subpaths with fewer than 3 distinct points are
+// ignored. The subpaths are classified by the "nonzero" fill rule of
+// SVG: a subpath that bounds a filled region from the unfilled
+// region around it is appended counter-clockwise, as an outer
+// polygon, and one that bounds an unfilled region inside a filled
+// one is appended clockwise, as a hole. Subpaths that separate two
+// filled regions are omitted. Only the relative directions of the
+// subpaths matter, so a lone subpath is filled whichever way it is
+// wound. The classification assumes the subpaths do not cross
+// themselves or each other.
+func (pen *Pen) FillPath(s *polygon.Shapes, d string) (*polygon.Shapes, error) {
+	paths, err := pen.ParsePath(d)
+	if err != nil {
+		return s, err
+	}
+	var polys [][]polygon.Point
+	for _, p := range paths {
+		if pts := closed(p.Pts); len(pts) >= 3 {
+			polys = append(polys, pts)
+		}
+	}
+	for i, pts := range polys {
+		outside := 0
+		for j, other := range polys {
+			if j != i {
+				outside += winding(other, pts[0])
+			}
+		}
+		ccw := area(pts) > 0
+		inside := outside - 1
+		if ccw {
+			inside = outside + 1
+		}
+		switch {
+		case outside != 0 && inside != 0:
+			continue
+		case (outside == 0) != ccw:
+			pts = reverse(pts)
+		}
+		s = s.Builder(pts...)
+	}
+	return s, nil
+}
+
+// StrokePath appends to s the outlines of lines of the specified
+// width that follow each subpath of the SVG path data, d, as parsed
+// by (*Pen).ParsePath. Closed subpaths are rendered with
+// (*Pen).Loop, and open subpaths with (*Pen).Stroke with their ends
+// capped as indicated by caps.
+func (pen *Pen) StrokePath(s *polygon.Shapes, d string, width float64, caps Caps) (*polygon.Shapes, error) {
+	paths, err := pen.ParsePath(d)
+	if err != nil {
+		return s, err
+	}
+	for _, p := range paths {
+		if p.Closed {
+			s = pen.Loop(s, p.Pts, width)
+		} else {
+			s = pen.Stroke(s, p.Pts, width, caps)
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,120 @@
+package polymark
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"zappem.net/pub/math/polygon"
+)
+
+func TestParsePath(t *testing.T) {
+	pen := &Pen{Scribe: .1}
+	paths, err := pen.ParsePath("M0,0h10v10h-10z m2,2 v6 h6 v-6 z M1.5.5l-1-1e0 20 0")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	want := []Path{
+		{Pts: []polygon.Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}, Closed: true},
+		{Pts: []polygon.Point{{2, 2}, {2, 8}, {8, 8}, {8, 2}}, Closed: true},
+		{Pts: []polygon.Point{{1.5, .5}, {.5, -.5}, {20.5, -.5}}},
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("got %d paths, want %d: %v", len(paths), len(want), paths)
+	}
+	for i, p := range paths {
+		if p.Closed != want[i].Closed || len(p.Pts) != len(want[i].Pts) {
+			t.Errorf("[%d] got %v, want %v", i, p, want[i])
+			continue
+		}
+		for j, pt := range p.Pts {
+			if !polygon.MatchPoint(pt, want[i].Pts[j]) {
+				t.Errorf("[%d,%d] got %v, want %v", i, j, pt, want[i].Pts[j])
+			}
+		}
+	}
+
+	ts := []struct {
+		d      string
+		ll, tr polygon.Point
+	}{
+		{"M0 0 A10 10 0 0 1 20 0", polygon.Point{0, -10}, polygon.Point{20, 0}},
+		{"M0 0 a10 10 0 1 0 20 0", polygon.Point{0, 0}, polygon.Point{20, 10}},
+		{"M0 0 A10 5 90 0 1 0 20", polygon.Point{0, 0}, polygon.Point{5, 20}},
+		{"M0 0 Q10 10 20 0 T40 0", polygon.Point{0, -5}, polygon.Point{40, 5}},
+		{"M0 0 C0 10 10 10 10 0 S20 -10 20 0", polygon.Point{0, -7.5}, polygon.Point{20, 7.5}},
+		{"M0 0 c0 10 10 10 10 0 s10 -10 10 0", polygon.Point{0, -7.5}, polygon.Point{20, 7.5}},
+	}
+	for i, v := range ts {
+		paths, err := pen.ParsePath(v.d)
+		if err != nil {
+			t.Errorf("[%d] %q failed: %v", i, v.d, err)
+			continue
+		}
+		if len(paths) != 1 {
+			t.Errorf("[%d] got %d paths, want 1", i, len(paths))
+			continue
+		}
+		ll, tr := polygon.Point{math.Inf(1), math.Inf(1)}, polygon.Point{math.Inf(-1), math.Inf(-1)}
+		for _, pt := range paths[0].Pts {
+			ll.X, ll.Y = math.Min(ll.X, pt.X), math.Min(ll.Y, pt.Y)
+			tr.X, tr.Y = math.Max(tr.X, pt.X), math.Max(tr.Y, pt.Y)
+		}
+		if math.Abs(ll.X-v.ll.X) > .03 || math.Abs(ll.Y-v.ll.Y) > .03 || math.Abs(tr.X-v.tr.X) > .03 || math.Abs(tr.Y-v.tr.Y) > .03 {
+			t.Errorf("[%d] %q got BB=%v,%v want %v,%v", i, v.d, ll, tr, v.ll, v.tr)
+		}
+	}
+
+	for i, d := range []string{"L10 10", "M0 0 L10", "M0 0 X", "M0 0 Z 10", "M0 0 A1 1 0 2 1 3 3", "M0 0 L1e 5"} {
+		if _, err := pen.ParsePath(d); !errors.Is(err, ErrBadPath) {
+			t.Errorf("[%d] %q got err=%v, want %v", i, d, err, ErrBadPath)
+		}
+	}
+}
+
+func TestFillPath(t *testing.T) {
+	pen := &Pen{Scribe: .1}
+	d := "M0,0h10v10h-10z m2,2 v6 h6 v-6 z"
+	s, err := pen.FillPath(nil, d)
+	if err != nil {
+		t.Fatalf("fill failed: %v", err)
+	}
+	if len(s.P) != 2 || s.P[0].Hole || !s.P[1].Hole {
+		t.Errorf("expected shape and hole, got %v", s.P)
+	}
+	for _, d := range []string{"M0,0v10h10v-10z", "M0,0h10v10h-10z"} {
+		s, err := pen.FillPath(nil, d)
+		if err != nil {
+			t.Fatalf("fill %q failed: %v", d, err)
+		}
+		if len(s.P) != 1 || s.P[0].Hole {
+			t.Errorf("fill %q: expected one shape, got %v", d, s.P)
+		}
+	}
+	// Reversing every subpath, or nesting one wound the same way,
+	// does not change what is filled.
+	s, err = pen.FillPath(nil, "M0,0v10h10v-10z m2,2 h6 v6 h-6 z")
+	if err != nil {
+		t.Fatalf("fill failed: %v", err)
+	}
+	if len(s.P) != 2 || s.P[0].Hole || !s.P[1].Hole {
+		t.Errorf("expected reversed shape and hole, got %v", s.P)
+	}
+	s, err = pen.FillPath(nil, "M0,0h10v10h-10z m2,2 h6 v6 h-6 z")
+	if err != nil {
+		t.Fatalf("fill failed: %v", err)
+	}
+	if len(s.P) != 1 || s.P[0].Hole {
+		t.Errorf("expected one filled shape, got %v", s.P)
+	}
+	s, err = pen.StrokePath(nil, d+" M20 0 L30 0", 2, Caps{})
+	if err != nil {
+		t.Fatalf("stroke failed: %v", err)
+	}
+	if len(s.P) != 5 {
+		t.Errorf("got %d polygons, want 5", len(s.P))
+	}
+	if _, err := pen.StrokePath(nil, "M", 1, Caps{}); !errors.Is(err, ErrBadPath) {
+		t.Errorf("got err=%v, want %v", err, ErrBadPath)
+	}
+}
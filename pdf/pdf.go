@@ -0,0 +1,141 @@
+// Package pdf generates PDF documents that render polygon.Shapes as
+// filled paths, one set of shapes per page, for printed proofs and
+// documentation.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"zappem.net/pub/graphics/polymark/internal/plot"
+	"zappem.net/pub/math/polygon"
+)
+
+// Config holds the document configuration used to generate PDF.
+type Config struct {
+	// Inches selects inch units for the page size and the polygon
+	// coordinates, otherwise they are in millimeters.
+	Inches bool
+
+	// Width and Height are the page dimensions. Zero values imply
+	// an A4 page (210x297 mm), or a US Letter page (8.5x11 in) if
+	// Inches is true.
+	Width, Height float64
+
+	// Origin is the point in the polygon coordinates that maps to
+	// the lower left corner of each page, and Scale converts the
+	// polygon coordinates to page units. A Scale of zero implies
+	// 1.
+	Origin polygon.Point
+	Scale  float64
+
+	// Proof selects stroke-only rendering of the polygon outlines
+	// with lines of width ProofWidth (page units). A zero
+	// ProofWidth implies the thinnest line the output device can
+	// render.
+	Proof      bool
+	ProofWidth float64
+}
+
+// num formats a value in PDF points.
+func num(v float64) string {
+	return plot.Num(v, 3)
+}
+
+// content returns the page content stream that renders s.
+func (cfg *Config) content(s *polygon.Shapes) []byte {
+	var b bytes.Buffer
+	pts := plot.Points(cfg.Inches)
+	xform := plot.Transform{Origin: cfg.Origin, Scale: cfg.Scale * pts}
+	xy := func(pt polygon.Point) string {
+		pt = xform.Apply(pt)
+		return num(pt.X) + " " + num(pt.Y)
+	}
+	if cfg.Proof {
+		fmt.Fprintf(&b, "0 0 0 RG %s w 1 j\n", num(cfg.ProofWidth*pts))
+	} else {
+		b.WriteString("0 0 0 rg\n")
+	}
+	paths := false
+	if s != nil {
+		for _, p := range s.P {
+			if len(p.PS) < 2 {
+				continue
+			}
+			paths = true
+			fmt.Fprintf(&b, "%s m\n", xy(p.PS[0]))
+			for _, pt := range p.PS[1:] {
+				fmt.Fprintf(&b, "%s l\n", xy(pt))
+			}
+			b.WriteString("h\n")
+		}
+	}
+	if !paths {
+		return b.Bytes()
+	}
+	if cfg.Proof {
+		b.WriteString("S\n")
+	} else {
+		// The even-odd rule renders the holes.
+		b.WriteString("f*\n")
+	}
+	return b.Bytes()
+}
+
+// Write writes a PDF document to out with one page for each of the
+// pages. The polygons of each page are filled with black as a single
+// path using the even-odd rule, so holes are rendered as unfilled
+// regions. A nil cfg implies a zero Config.
+func Write(out io.Writer, cfg *Config, pages ...*polygon.Shapes) error {
+	c := &Config{}
+	if cfg != nil {
+		*c = *cfg
+	}
+	if c.Width == 0 || c.Height == 0 {
+		c.Width, c.Height = plot.PageSize(c.Inches)
+	}
+	if c.Scale == 0 {
+		c.Scale = 1
+	}
+	if len(pages) == 0 {
+		pages = []*polygon.Shapes{nil}
+	}
+
+	var b bytes.Buffer
+	var offsets []int
+	obj := func(format string, args ...interface{}) {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n", len(offsets))
+		fmt.Fprintf(&b, format, args...)
+		b.WriteString("\nendobj\n")
+	}
+
+	// Objects 1 and 2 are the catalog and the page tree, and
+	// each page is followed by its content stream.
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	var kids bytes.Buffer
+	for i := range pages {
+		if i != 0 {
+			kids.WriteString(" ")
+		}
+		fmt.Fprintf(&kids, "%d 0 R", 3+2*i)
+	}
+	pts := plot.Points(c.Inches)
+	obj("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %s %s] >>", kids.String(), len(pages), num(c.Width*pts), num(c.Height*pts))
+	for i, s := range pages {
+		obj("<< /Type /Page /Parent 2 0 R /Resources << >> /Contents %d 0 R >>", 4+2*i)
+		data := c.content(s)
+		obj("<< /Length %d >>\nstream\n%sendstream", len(data), data)
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	_, err := out.Write(b.Bytes())
+	return err
+}
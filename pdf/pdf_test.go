@@ -0,0 +1,64 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"zappem.net/pub/math/polygon"
+)
+
+func TestWrite(t *testing.T) {
+	var s *polygon.Shapes
+	s = s.Builder(polygon.Point{0, 0}, polygon.Point{1, 0}, polygon.Point{1, 1})
+	s = s.Builder(polygon.Point{0.5, 0.25}, polygon.Point{0.75, 0.5}, polygon.Point{0.75, 0.25})
+	var b bytes.Buffer
+	if err := Write(&b, &Config{Inches: true, Width: 2, Height: 1}, s, nil); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	doc := b.String()
+	for _, want := range []string{
+		"/Kids [3 0 R 5 0 R] /Count 2 /MediaBox [0 0 144 72]",
+		"<< /Length 61 >>\nstream\n0 0 0 rg\n0 0 m\n72 0 l\n72 72 l\nh\n36 18 m\n54 36 l\n54 18 l\nh\nf*\nendstream",
+		"<< /Length 9 >>\nstream\n0 0 0 rg\nendstream",
+		"trailer\n<< /Size 7 /Root 1 0 R >>",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("document does not contain %q:\n%s", want, doc)
+		}
+	}
+
+	// Confirm the cross reference table locates every object.
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindStringSubmatch(doc)
+	if m == nil {
+		t.Fatalf("no startxref in:\n%s", doc)
+	}
+	xref, _ := strconv.Atoi(m[1])
+	if !strings.HasPrefix(doc[xref:], "xref\n0 7\n") {
+		t.Fatalf("startxref %d does not locate xref table", xref)
+	}
+	lines := strings.Split(doc[xref:], "\n")
+	for i := 1; i < 7; i++ {
+		off, _ := strconv.Atoi(lines[2+i][:10])
+		if want := fmt.Sprintf("%d 0 obj\n", i); !strings.HasPrefix(doc[off:], want) {
+			t.Errorf("xref for object %d locates %q", i, doc[off:off+10])
+		}
+	}
+
+	b.Reset()
+	if err := Write(&b, &Config{Proof: true, ProofWidth: 0.1}, s); err != nil {
+		t.Fatalf("proof write failed: %v", err)
+	}
+	for _, want := range []string{
+		"/MediaBox [0 0 595.276 841.89]",
+		"0 0 0 RG 0.283 w 1 j\n",
+		"h\nS\nendstream",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("proof document does not contain %q:\n%s", want, b.String())
+		}
+	}
+}
@@ -23,16 +23,35 @@ type Pen struct {
 	// attribute of the Pen causes the Y component of the font to
 	// be negated, increasing Y at the upper edges of the Glyph.
 	Reflect bool
+
+	// Join selects how (*Pen).Line renders the corners where
+	// consecutive line segments meet. The default, JoinOverlap,
+	// renders each segment as a separate overlapping outline. The
+	// other JoinStyle values render each line as a single
+	// outline.
+	Join JoinStyle
+
+	// MiterLimit bounds the ratio of the miter length to the line
+	// width for JoinMiter corners. Corners that are sharper than
+	// this are rendered as if JoinBevel was selected. Values less
+	// than 1 imply a limit of 4.
+	MiterLimit float64
 }
 
-// circle constructs an approximate circle polygon with points
-// rotationally offset by theta.
-func (pen *Pen) circle(s *polygon.Shapes, pt polygon.Point, r, theta float64) *polygon.Shapes {
+// segments returns the number of straight segments used to
+// approximate a full circle of radius r.
+func (pen *Pen) segments(r float64) float64 {
 	n := math.Floor(4 * r / pen.Scribe)
 	if n < 4 {
 		n = 4
 	}
-	n *= 4 // want a multiple of 4 for symmetry
+	return n * 4 // want a multiple of 4 for symmetry
+}
+
+// circle constructs an approximate circle polygon with points
+// rotationally offset by theta.
+func (pen *Pen) circle(s *polygon.Shapes, pt polygon.Point, r, theta float64) *polygon.Shapes {
+	n := pen.segments(r)
 	ang := 2 * math.Pi / n
 	var pts []polygon.Point
 	for i := 0.0; i < n; i++ {
@@ -52,8 +71,16 @@ func (pen *Pen) Circle(s *polygon.Shapes, pt polygon.Point, r float64) *polygon.
 // Line constructs the outline of a series of straight line segments
 // of a specified width. The corners of the line are rounded if midCap
 // is true, and the endCap value determines if the ends of the line
-// are rounded.
+// are rounded. If pen.Join is not JoinOverlap, the midCap value is
+// ignored and the corners are joined in the pen.Join style.
 func (pen *Pen) Line(s *polygon.Shapes, pts []polygon.Point, width float64, midCap, endCap bool) *polygon.Shapes {
+	if pen.Join != JoinOverlap {
+		caps := Caps{}
+		if endCap {
+			caps.Start, caps.End = CapRound, CapRound
+		}
+		return pen.stroke(s, pts, width/2, caps)
+	}
 	var last polygon.Point
 	var working *polygon.Shapes
 	half := width / 2
@@ -180,6 +207,56 @@ func (pen *Pen) Spiral(s *polygon.Shapes, from, to, pt polygon.Point, width floa
 	return pen.Line(s, pts, width, midCap, endCap), nil
 }
 
+// SpiralStroke is the same as Spiral, but the spiral is rendered as a
+// single polygon outline by (*Pen).Stroke with the ends capped as
+// indicated by caps.
+func (pen *Pen) SpiralStroke(s *polygon.Shapes, from, to, pt polygon.Point, width float64, dir bool, winding uint, caps Caps) (*polygon.Shapes, error) {
+	pts, err := spiral(width, from, to, pt, dir, winding)
+	if err != nil {
+		return s, err
+	}
+	return pen.Stroke(s, pts, width, caps), nil
+}
+
+// Arc constructs the outline of a circular arc of the specified
+// width and radius around center. The arc starts at angle startAngle
+// (radians) and sweeps counter-clockwise by sweepAngle, or clockwise
+// for negative values. The ends of the arc are capped as indicated
+// by caps, except for sweeps of a full turn or more, which cover the
+// whole circle and are rendered as a ring with (*Pen).Loop. A zero
+// sweep or radius has no solution.
+func (pen *Pen) Arc(s *polygon.Shapes, center polygon.Point, radius, startAngle, sweepAngle, width float64, caps Caps) (*polygon.Shapes, error) {
+	if radius <= 0 {
+		return s, ErrNoSolution
+	}
+	sweep := math.Abs(sweepAngle)
+	if sweep < straight {
+		return s, ErrNoSolution
+	}
+	from := polygon.Point{
+		X: center.X + radius*math.Cos(startAngle),
+		Y: center.Y + radius*math.Sin(startAngle),
+	}
+	if twoPi-sweep < straight {
+		// Retracing the circle would overlap the arc with
+		// itself, so render the ring it covers.
+		pts, err := spiral(width, from, from, center, true, 1)
+		if err != nil {
+			return s, err
+		}
+		return pen.Loop(s, pts, width), nil
+	}
+	to := polygon.Point{
+		X: center.X + radius*math.Cos(startAngle+sweepAngle),
+		Y: center.Y + radius*math.Sin(startAngle+sweepAngle),
+	}
+	pts, err := spiral(width, from, to, center, sweepAngle > 0, 0)
+	if err != nil {
+		return s, err
+	}
+	return pen.Stroke(s, pts, width, caps), nil
+}
+
 // Alignment holds the horizontal and vertical alignment for rendering
 // text.
 type Alignment int
@@ -195,25 +272,131 @@ const (
 	AlignBelow  Alignment = 8
 )
 
+// textScale returns the horizontal and vertical output units per
+// font unit and the width of the lines used to render text at the
+// specified scale.
+func (pen *Pen) textScale(scale float64) (xScale, yScale, wScale float64) {
+	xScale = pen.Scribe * scale
+	wScale = xScale * 1.8
+	if scale <= 1.0 {
+		xScale = pen.Scribe
+	}
+	yScale = xScale
+	if pen.Reflect {
+		yScale = -yScale
+	}
+	return
+}
+
 // Text renders some text as a series of polygon outlines. For scale
 // >= 1.0 the enclosed polygon will have width scale*pen.Scribe, and
 // the rendered font will also be scaled.  A scale of < 1.0 renders
 // the characters at native size of pen.Scribe per pixel but with less
 // and less of a width for the lines.
 func (pen *Pen) Text(s *polygon.Shapes, x, y, scale float64, a Alignment, font *hershey.Font, text string) *polygon.Shapes {
-	gl, xL, xR := font.Text(text)
-	xScale := pen.Scribe * scale
-	wScale := xScale * 1.8
-	if scale <= 1.0 {
-		xScale = pen.Scribe
+	return pen.TextWith(s, x, y, scale, a, font, text, nil)
+}
+
+// TextOptions holds the optional parameters for rendering text with
+// (*Pen).TextWith, and the other methods of Pen with names ending in
+// With.
+type TextOptions struct {
+	// Angle rotates the text, counter-clockwise by Angle radians,
+	// about the (x,y) anchor point. The alignment is applied
+	// before the rotation, so it is relative to the direction of
+	// the text. In images, where Y increases down the page, the
+	// rotation appears clockwise.
+	Angle float64
+
+	// Tracking is extra space, in output units, added between
+	// each pair of consecutive glyphs. Negative values tighten
+	// the text.
+	Tracking float64
+
+	// Kerning holds additional spacing, in output units, for
+	// specific pairs of consecutive runes. For example, a value
+	// of -1 for [2]rune{'A', 'V'} moves a V that follows an A one
+	// unit closer to it.
+	Kerning map[[2]rune]float64
+
+	// Monospace, if non-zero, places every glyph centered in a
+	// cell of this width (output units). Tracking separates the
+	// cells, and Kerning is ignored. Unlike proportional text,
+	// leading spaces are kept, so they can pad the columns of a
+	// table.
+	Monospace float64
+
+	// Stretch, if non-zero, multiplies the horizontal dimensions
+	// of the text, but not the width of its lines. Values less
+	// than 1 condense the text. See (*Pen).FitText.
+	Stretch float64
+
+	// Height, if non-zero, sets the size of the glyphs, in output
+	// units, as the height of the font's capital H. The scale
+	// argument then only selects the default line width.
+	Height float64
+
+	// Weight, if non-zero, is the width of the lines, in output
+	// units, used to render the glyphs. Otherwise, the width
+	// follows from the scale, as for (*Pen).Text.
+	Weight float64
+}
+
+// capHeight returns the height of the ink of the font's capital H,
+// in font units, or zero if the font has no such glyph.
+func capHeight(font *hershey.Font) float64 {
+	gl, _, _ := font.Text("H")
+	var top, bottom int
+	first := true
+	for _, line := range gl.Strokes {
+		for _, pt := range line {
+			if first || pt[1] < top {
+				top = pt[1]
+			}
+			if first || pt[1] > bottom {
+				bottom = pt[1]
+			}
+			first = false
+		}
 	}
-	yScale := xScale
-	if pen.Reflect {
-		yScale = -yScale
+	return float64(bottom - top)
+}
+
+// scales returns the horizontal and vertical output units per font
+// unit and the line width used to render text at the specified
+// scale, as (*Pen).textScale, but adjusted for the Height and Weight
+// options.
+func (opts *TextOptions) scales(pen *Pen, scale float64, font *hershey.Font) (xScale, yScale, wScale float64) {
+	xScale, yScale, wScale = pen.textScale(scale)
+	if opts.Height > 0 {
+		if h := capHeight(font); h > 0 {
+			f := opts.Height / (h * xScale)
+			xScale, yScale = xScale*f, yScale*f
+		}
+	}
+	if opts.Weight > 0 {
+		wScale = opts.Weight
 	}
+	return
+}
+
+// TextWith renders text in the same way as (*Pen).Text, but with the
+// optional parameters held in opts. A nil opts is equivalent to
+// (*Pen).Text.
+func (pen *Pen) TextWith(s *polygon.Shapes, x, y, scale float64, a Alignment, font *hershey.Font, text string, opts *TextOptions) *polygon.Shapes {
+	if opts == nil {
+		opts = &TextOptions{}
+	}
+	gl, _, _ := font.Text(text)
+	xScale, yScale, wScale := opts.scales(pen, scale, font)
+	strokes, xL, xR := opts.strokes(font, text, xScale)
+	if opts.Stretch != 0 {
+		xScale *= opts.Stretch
+	}
+
 	var x0, y0 float64
-	trX := func(x int) float64 {
-		return x0 + float64(x)*xScale
+	trX := func(x float64) float64 {
+		return x0 + x*xScale
 	}
 	trY := func(y int) float64 {
 		return y0 + float64(y)*yScale
@@ -236,15 +419,23 @@ func (pen *Pen) Text(s *polygon.Shapes, x, y, scale float64, a Alignment, font *
 		y0 = y - trY(gl.Bottom)
 	}
 
-	for _, line := range gl.Strokes {
+	sin, cos := math.Sincos(opts.Angle)
+	for _, line := range strokes {
 		if len(line) == 0 {
 			continue
 		}
 		var pts []polygon.Point
 		for _, pt := range line {
 			to := polygon.Point{
-				X: trX(pt[0]),
-				Y: trY(pt[1]),
+				X: trX(pt.X),
+				Y: y0 + pt.Y*yScale,
+			}
+			if opts.Angle != 0 {
+				dX, dY := to.X-x, to.Y-y
+				to = polygon.Point{
+					X: x + dX*cos - dY*sin,
+					Y: y + dX*sin + dY*cos,
+				}
 			}
 			pts = append(pts, to)
 		}
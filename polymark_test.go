@@ -338,3 +338,55 @@ func TestSpiral(t *testing.T) {
 		}
 	}
 }
+
+func TestArc(t *testing.T) {
+	pen := &Pen{Scribe: .5}
+	c := polygon.Point{0, 0}
+	ts := []struct {
+		start, sweep float64
+		n            int
+		ll, tr       polygon.Point
+	}{
+		{0, math.Pi / 2, 1, polygon.Point{0, 0}, polygon.Point{11, 11}},
+		{0, -math.Pi / 2, 1, polygon.Point{0, -11}, polygon.Point{11, 0}},
+		{math.Pi / 2, math.Pi, 1, polygon.Point{-11, -11}, polygon.Point{0, 11}},
+		{0, 3 * math.Pi, 2, polygon.Point{-11, -11}, polygon.Point{11, 11}},
+		{0, -2.5 * math.Pi, 2, polygon.Point{-11, -11}, polygon.Point{11, 11}},
+		{1, 2 * math.Pi, 2, polygon.Point{-11, -11}, polygon.Point{11, 11}},
+		{1, -4 * math.Pi, 2, polygon.Point{-11, -11}, polygon.Point{11, 11}},
+	}
+	for i, v := range ts {
+		s, err := pen.Arc(nil, c, 10, v.start, v.sweep, 2, Caps{})
+		if err != nil {
+			t.Fatalf("[%d] failed: %v", i, err)
+		}
+		if len(s.P) != v.n {
+			t.Errorf("[%d] got %d polygons, want %d", i, len(s.P), v.n)
+		}
+		ll, tr := s.BB()
+		if math.Abs(ll.X-v.ll.X) > .1 || math.Abs(ll.Y-v.ll.Y) > .1 || math.Abs(tr.X-v.tr.X) > .1 || math.Abs(tr.Y-v.tr.Y) > .1 {
+			t.Errorf("[%d] got BB=%v,%v want %v,%v", i, ll, tr, v.ll, v.tr)
+		}
+	}
+	// Sweeps of a non-integer number of turns cover the whole ring
+	// exactly once.
+	for _, sweep := range []float64{3 * math.Pi, -2.5 * math.Pi, 7.2} {
+		s, err := pen.Arc(nil, c, 10, 0.3, sweep, 2, Caps{})
+		if err != nil {
+			t.Fatalf("sweep=%g failed: %v", sweep, err)
+		}
+		got := 0.0
+		for _, p := range s.P {
+			got += area(p.PS)
+		}
+		if want := math.Pi * (11*11 - 9*9); math.Abs(got-want) > .01*want {
+			t.Errorf("sweep=%g got area=%g want=%g", sweep, got, want)
+		}
+	}
+	if _, err := pen.Arc(nil, c, 10, 0, 0, 2, Caps{}); err == nil {
+		t.Error("zero sweep arc should not be possible")
+	}
+	if _, err := pen.Arc(nil, c, 0, 0, 1, 2, Caps{}); err == nil {
+		t.Error("zero radius arc should not be possible")
+	}
+}
@@ -0,0 +1,297 @@
+// Package preview renders polygon.Shapes into raster images, such as
+// PNG previews of the output of the polymark package.
+package preview
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sort"
+
+	"zappem.net/pub/graphics/polymark"
+	"zappem.net/pub/math/polygon"
+)
+
+// Options holds the rendering options for Render.
+type Options struct {
+	// Outline and Hole are the colors of the lines drawn along
+	// the outlines of the outer boundaries and holes of the
+	// shapes. Nil values imply red and blue respectively.
+	Outline, Hole color.Color
+
+	// Weight is the width, in pixels, of the outline lines. Zero
+	// implies 1, and negative values omit the outlines.
+	Weight float64
+
+	// Fill, if not nil, is the color used to fill the shapes with
+	// the even-odd rule, so holes are left unfilled.
+	Fill color.Color
+
+	// Hatch, if non-zero, is the separation of 1 pixel wide
+	// hatch lines of color HatchColor that fill the shapes. These
+	// lines are at an angle of HatchAngle radians counter-clockwise
+	// from the horizontal axis of the shapes, and come as close to
+	// the outlines as Scribe/2. Hatch and Scribe are in the units
+	// of the shapes. A Scribe of zero implies Hatch/2, and a nil
+	// HatchColor implies a pale purple.
+	Hatch, HatchAngle, Scribe float64
+	HatchColor                color.Color
+
+	// FlipY renders increasing Y values of the shapes up the
+	// image. The polygon package has Y increasing up the page,
+	// and images have it increasing down the page.
+	FlipY bool
+
+	// Fit scales and centers the shapes to fill the image, less a
+	// Margin of pixels on each side. Otherwise, the point Origin
+	// of the shapes maps to the top left corner (bottom left with
+	// FlipY) of the image, and the shapes are magnified by Scale.
+	// A Scale of zero implies 1.
+	Fit    bool
+	Margin float64
+	Origin polygon.Point
+	Scale  float64
+
+	// Aliased disables antialiasing, so every pixel is either
+	// entirely colored or left unchanged.
+	Aliased bool
+}
+
+// samples is the number of sub-scanlines per row of pixels used to
+// antialias filled regions.
+const samples = 4
+
+// mask accumulates the pixel coverage of filled regions.
+type mask struct {
+	w, h    int
+	cov     []float64
+	aliased bool
+}
+
+// crossing is where an edge crosses a scanline, with the winding
+// direction of the edge.
+type crossing struct {
+	x   float64
+	dir int
+}
+
+// fill adds the coverage of the polygons, polys, in pixel
+// coordinates. Regions are filled with the even-odd rule, or with
+// the non-zero winding rule if nonZero is true.
+func (m *mask) fill(polys [][]polygon.Point, nonZero bool) {
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, pts := range polys {
+		for _, pt := range pts {
+			minY, maxY = math.Min(minY, pt.Y), math.Max(maxY, pt.Y)
+		}
+	}
+	n, weight := samples, 1.0/samples
+	if m.aliased {
+		n, weight = 1, 1
+	}
+	var xs []crossing
+	for row := int(math.Max(0, math.Floor(minY))); row < m.h && float64(row) <= maxY; row++ {
+		for k := 0; k < n; k++ {
+			y := float64(row) + (float64(k)+0.5)/float64(n)
+			xs = xs[:0]
+			for _, pts := range polys {
+				for i, b := range pts {
+					a := pts[(i+len(pts)-1)%len(pts)]
+					if (a.Y <= y) == (b.Y <= y) {
+						continue
+					}
+					dir := 1
+					if a.Y > b.Y {
+						dir = -1
+					}
+					xs = append(xs, crossing{x: a.X + (y-a.Y)*(b.X-a.X)/(b.Y-a.Y), dir: dir})
+				}
+			}
+			sort.Slice(xs, func(i, j int) bool { return xs[i].x < xs[j].x })
+			wind, start := 0, 0.0
+			for _, c := range xs {
+				was := wind
+				if nonZero {
+					wind += c.dir
+				} else {
+					wind ^= 1
+				}
+				if was == 0 {
+					start = c.x
+				} else if wind == 0 {
+					m.span(row, start, c.x, weight)
+				}
+			}
+		}
+	}
+}
+
+// span adds coverage weight to the pixels of row between x0 and x1.
+func (m *mask) span(row int, x0, x1, weight float64) {
+	base := row * m.w
+	if m.aliased {
+		for x := int(math.Max(0, math.Ceil(x0-0.5))); x < m.w && float64(x)+0.5 < x1; x++ {
+			m.cov[base+x] += weight
+		}
+		return
+	}
+	for x := int(math.Max(0, math.Floor(x0))); x < m.w && float64(x) < x1; x++ {
+		if c := math.Min(x1, float64(x+1)) - math.Max(x0, float64(x)); c > 0 {
+			m.cov[base+x] += c * weight
+		}
+	}
+}
+
+// draw composites col onto im through the accumulated coverage, and
+// clears the coverage.
+func (m *mask) draw(im draw.Image, col color.Color) {
+	b := im.Bounds()
+	alpha := image.NewAlpha(b)
+	for i, c := range m.cov {
+		if c > 0 {
+			alpha.Pix[i] = uint8(math.Round(255 * math.Min(1, c)))
+		}
+		m.cov[i] = 0
+	}
+	draw.DrawMask(im, b, image.NewUniform(col), image.Point{}, alpha, b.Min, draw.Over)
+}
+
+// line returns a rectangle, in pixel coordinates, that renders a
+// line of width w from a to b, with square ends.
+func line(a, b polygon.Point, w float64) []polygon.Point {
+	h := w / 2
+	dX, dY := b.X-a.X, b.Y-a.Y
+	if d := math.Hypot(dX, dY); d > 0 {
+		dX, dY = h*dX/d, h*dY/d
+	} else {
+		dX = h
+	}
+	a, b = polygon.Point{X: a.X - dX, Y: a.Y - dY}, polygon.Point{X: b.X + dX, Y: b.Y + dY}
+	return []polygon.Point{
+		{X: a.X + dY, Y: a.Y - dX},
+		{X: b.X + dY, Y: b.Y - dX},
+		{X: b.X - dY, Y: b.Y + dX},
+		{X: a.X - dY, Y: a.Y + dX},
+	}
+}
+
+// Render draws the shapes, s, into im. The shapes are first filled,
+// then hatched, and finally their outlines are drawn, as configured
+// by opts. A nil opts implies a zero Options value, which outlines
+// the shapes at their native scale.
+func Render(im draw.Image, s *polygon.Shapes, opts *Options) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if s == nil || len(s.P) == 0 {
+		return nil
+	}
+	b := im.Bounds()
+	scale, origin := opts.Scale, opts.Origin
+	if scale == 0 {
+		scale = 1
+	}
+	oX, oY := 0.0, 0.0
+	if opts.Fit {
+		ll, tr := s.BB()
+		wide, high := float64(b.Dx())-2*opts.Margin, float64(b.Dy())-2*opts.Margin
+		scale = math.Inf(1)
+		if dX := tr.X - ll.X; dX > 0 {
+			scale = wide / dX
+		}
+		if dY := tr.Y - ll.Y; dY > 0 {
+			scale = math.Min(scale, high/dY)
+		}
+		if math.IsInf(scale, 1) {
+			scale = 1
+		}
+		origin = polygon.Point{X: (ll.X + tr.X) / 2, Y: (ll.Y + tr.Y) / 2}
+		oX, oY = float64(b.Dx())/2, float64(b.Dy())/2
+	}
+	yScale := scale
+	if opts.FlipY {
+		yScale = -scale
+		if !opts.Fit {
+			oY = float64(b.Dy())
+		}
+	}
+	px := func(pt polygon.Point) polygon.Point {
+		return polygon.Point{X: oX + (pt.X-origin.X)*scale, Y: oY + (pt.Y-origin.Y)*yScale}
+	}
+
+	m := &mask{w: b.Dx(), h: b.Dy(), cov: make([]float64, b.Dx()*b.Dy()), aliased: opts.Aliased}
+	if opts.Fill != nil {
+		var polys [][]polygon.Point
+		for _, p := range s.P {
+			var pts []polygon.Point
+			for _, pt := range p.PS {
+				pts = append(pts, px(pt))
+			}
+			polys = append(polys, pts)
+		}
+		m.fill(polys, false)
+		m.draw(im, opts.Fill)
+	}
+
+	if opts.Hatch != 0 {
+		scribe := opts.Scribe
+		if scribe == 0 {
+			scribe = opts.Hatch / 2
+		}
+		lines, err := polymark.Hatch(s, scribe, opts.Hatch, opts.HatchAngle)
+		if err != nil {
+			return err
+		}
+		var polys [][]polygon.Point
+		for _, l := range lines {
+			polys = append(polys, line(px(l.From), px(l.To), 1))
+		}
+		col := opts.HatchColor
+		if col == nil {
+			col = color.RGBA{0xb0, 0xa0, 0xf0, 0xff}
+		}
+		if len(polys) != 0 {
+			m.fill(polys, true)
+			m.draw(im, col)
+		}
+	}
+
+	if opts.Weight >= 0 {
+		weight := opts.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		outline, hole := opts.Outline, opts.Hole
+		if outline == nil {
+			outline = color.RGBA{0xff, 0, 0, 0xff}
+		}
+		if hole == nil {
+			hole = color.RGBA{0, 0, 0xff, 0xff}
+		}
+		for _, holes := range []bool{false, true} {
+			var polys [][]polygon.Point
+			for _, p := range s.P {
+				if p.Hole != holes || len(p.PS) == 0 {
+					continue
+				}
+				from := px(p.PS[len(p.PS)-1])
+				for _, pt := range p.PS {
+					to := px(pt)
+					polys = append(polys, line(from, to, weight))
+					from = to
+				}
+			}
+			if len(polys) == 0 {
+				continue
+			}
+			m.fill(polys, true)
+			if holes {
+				m.draw(im, hole)
+			} else {
+				m.draw(im, outline)
+			}
+		}
+	}
+	return nil
+}
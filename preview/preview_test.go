@@ -0,0 +1,116 @@
+package preview
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"zappem.net/pub/math/polygon"
+)
+
+// display renders the non-white pixels of im as ASCII art.
+func display(im *image.RGBA) (lines []string) {
+	b := im.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		var line []byte
+		for x := b.Min.X; x < b.Max.X; x++ {
+			switch c := im.RGBAAt(x, y); c {
+			case color.RGBA{0xff, 0xff, 0xff, 0xff}:
+				line = append(line, '.')
+			case color.RGBA{0, 0, 0, 0xff}:
+				line = append(line, '#')
+			case color.RGBA{0xff, 0, 0, 0xff}:
+				line = append(line, 'R')
+			case color.RGBA{0, 0, 0xff, 0xff}:
+				line = append(line, 'B')
+			default:
+				line = append(line, '?')
+			}
+		}
+		lines = append(lines, string(line))
+	}
+	return
+}
+
+func TestRender(t *testing.T) {
+	var s *polygon.Shapes
+	s = s.Builder(polygon.Point{0, 0}, polygon.Point{4, 0}, polygon.Point{4, 3}, polygon.Point{0, 3})
+	s = s.Builder(polygon.Point{1, 1}, polygon.Point{1, 2}, polygon.Point{2, 2}, polygon.Point{2, 1})
+	ts := []struct {
+		opts *Options
+		want []string
+	}{
+		{
+			opts: &Options{Fill: color.Black, Weight: -1, Scale: 2, Aliased: true},
+			want: []string{
+				"########..",
+				"########..",
+				"##..####..",
+				"##..####..",
+				"########..",
+				"########..",
+				"..........",
+			},
+		},
+		{
+			opts: &Options{Fill: color.Black, Weight: -1, Fit: true, Margin: 1, FlipY: true, Aliased: true},
+			want: []string{
+				"..........",
+				"..........",
+				".########.",
+				".########.",
+				".##..####.",
+				".##..####.",
+				".########.",
+				".########.",
+				"..........",
+				"..........",
+			},
+		},
+		{
+			opts: &Options{Scale: 2, Origin: polygon.Point{-0.75, -0.75}, Aliased: true},
+			want: []string{
+				"..........",
+				".RRRRRRRRR",
+				".R.......R",
+				".R.BBB...R",
+				".R.B.B...R",
+				".R.BBB...R",
+				".R.......R",
+				".RRRRRRRRR",
+			},
+		},
+	}
+	for i, v := range ts {
+		high := len(v.want)
+		im := image.NewRGBA(image.Rect(0, 0, len(v.want[0]), high))
+		for j := range im.Pix {
+			im.Pix[j] = 0xff
+		}
+		if err := Render(im, s, v.opts); err != nil {
+			t.Fatalf("[%d] render failed: %v", i, err)
+		}
+		got := display(im)
+		for j, line := range got {
+			if line != v.want[j] {
+				t.Errorf("[%d:%d] got=%q want=%q", i, j, line, v.want[j])
+			}
+		}
+	}
+
+	// Antialiased edges partially cover pixels.
+	im := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	s = (*polygon.Shapes)(nil).Builder(polygon.Point{0, 0}, polygon.Point{1.5, 0}, polygon.Point{1.5, 4}, polygon.Point{0, 4})
+	if err := Render(im, s, &Options{Fill: color.Black, Weight: -1}); err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if got := im.RGBAAt(0, 1).A; got != 0xff {
+		t.Errorf("covered pixel alpha got=%d want=255", got)
+	}
+	if got := im.RGBAAt(1, 1).A; got != 0x80 {
+		t.Errorf("half covered pixel alpha got=%d want=128", got)
+	}
+	if got := im.RGBAAt(2, 1).A; got != 0 {
+		t.Errorf("uncovered pixel alpha got=%d want=0", got)
+	}
+}
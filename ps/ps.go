@@ -0,0 +1,132 @@
+// Package ps generates PostScript and Encapsulated PostScript (EPS)
+// documents that render polygon.Shapes as filled paths, one set of
+// shapes per page, for printed proofs and documentation.
+package ps
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"zappem.net/pub/graphics/polymark/internal/plot"
+	"zappem.net/pub/math/polygon"
+)
+
+// ErrPages indicates an attempt to write an EPS document with other
+// than one page.
+var ErrPages = errors.New("eps requires exactly one page")
+
+// Config holds the document configuration used to generate
+// PostScript.
+type Config struct {
+	// EPS selects Encapsulated PostScript, for embedding the
+	// output in other documents. An EPS file holds a single page,
+	// and does not set the page size of the output device.
+	EPS bool
+
+	// Inches selects inches, rather than millimeters, as the unit
+	// of the page size and of the scaled polygon coordinates.
+	// Either way, they are converted to PostScript points in the
+	// output.
+	Inches bool
+
+	// Width and Height are the page size, which is declared as the
+	// %%BoundingBox of the document and, unless EPS is true,
+	// requested with setpagedevice. Zero values select an A4 page,
+	// or a US Letter page if Inches is true.
+	Width, Height float64
+
+	// Origin is the point of the polygon coordinates that is placed
+	// at the PostScript default origin, the lower left corner of
+	// the page. Scale multiplies the polygon coordinates to obtain
+	// page units, and zero implies 1.
+	Origin polygon.Point
+	Scale  float64
+
+	// Proof strokes the polygon outlines with a setlinewidth of
+	// ProofWidth page units, instead of filling them. A zero
+	// ProofWidth draws the thinnest line the printer can.
+	Proof      bool
+	ProofWidth float64
+}
+
+// num formats a value in PostScript points.
+func num(v float64) string {
+	return plot.Num(v, 3)
+}
+
+// Write writes a PostScript document to out with one page for each
+// of the pages. The polygons of a page are combined into one path,
+// which eofill paints black, so holes are left unpainted. An EPS
+// document must have exactly one page. A nil cfg writes A4 pages of
+// unscaled millimeter coordinates.
+func Write(out io.Writer, cfg *Config, pages ...*polygon.Shapes) error {
+	c := &Config{}
+	if cfg != nil {
+		*c = *cfg
+	}
+	if c.Width == 0 || c.Height == 0 {
+		c.Width, c.Height = plot.PageSize(c.Inches)
+	}
+	if c.Scale == 0 {
+		c.Scale = 1
+	}
+	if len(pages) == 0 {
+		pages = []*polygon.Shapes{nil}
+	}
+	if c.EPS && len(pages) != 1 {
+		return ErrPages
+	}
+	pts := plot.Points(c.Inches)
+	w, h := c.Width*pts, c.Height*pts
+	xform := plot.Transform{Origin: c.Origin, Scale: c.Scale * pts}
+	xy := func(pt polygon.Point) string {
+		pt = xform.Apply(pt)
+		return num(pt.X) + " " + num(pt.Y)
+	}
+
+	b := bufio.NewWriter(out)
+	if c.EPS {
+		b.WriteString("%!PS-Adobe-3.0 EPSF-3.0\n")
+	} else {
+		b.WriteString("%!PS-Adobe-3.0\n")
+	}
+	fmt.Fprintf(b, "%%%%BoundingBox: 0 0 %d %d\n", int(math.Ceil(w)), int(math.Ceil(h)))
+	fmt.Fprintf(b, "%%%%HiResBoundingBox: 0 0 %s %s\n", num(w), num(h))
+	fmt.Fprintf(b, "%%%%Pages: %d\n%%%%EndComments\n", len(pages))
+	if !c.EPS {
+		fmt.Fprintf(b, "%%%%BeginSetup\n<< /PageSize [%s %s] >> setpagedevice\n%%%%EndSetup\n", num(w), num(h))
+	}
+	for i, s := range pages {
+		fmt.Fprintf(b, "%%%%Page: %d %d\n", i+1, i+1)
+		paths := false
+		if s != nil {
+			for _, p := range s.P {
+				if len(p.PS) < 2 {
+					continue
+				}
+				if !paths {
+					b.WriteString("newpath\n")
+					paths = true
+				}
+				fmt.Fprintf(b, "%s moveto\n", xy(p.PS[0]))
+				for _, pt := range p.PS[1:] {
+					fmt.Fprintf(b, "%s lineto\n", xy(pt))
+				}
+				b.WriteString("closepath\n")
+			}
+		}
+		if paths {
+			if c.Proof {
+				fmt.Fprintf(b, "0 setgray %s setlinewidth 1 setlinejoin stroke\n", num(c.ProofWidth*pts))
+			} else {
+				b.WriteString("0 setgray eofill\n")
+			}
+		}
+		b.WriteString("showpage\n")
+	}
+	b.WriteString("%%Trailer\n%%EOF\n")
+	return b.Flush()
+}
@@ -0,0 +1,107 @@
+package ps
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"zappem.net/pub/math/polygon"
+)
+
+func TestWrite(t *testing.T) {
+	// A square with a square hole, which eofill leaves unpainted.
+	var s *polygon.Shapes
+	s = s.Builder(polygon.Point{0, 0}, polygon.Point{2, 0}, polygon.Point{2, 2}, polygon.Point{0, 2})
+	s = s.Builder(polygon.Point{0.5, 0.5}, polygon.Point{0.5, 1.5}, polygon.Point{1.5, 1.5}, polygon.Point{1.5, 0.5})
+	ts := []struct {
+		cfg   *Config
+		pages []*polygon.Shapes
+		want  string
+	}{
+		{
+			cfg:   &Config{EPS: true, Inches: true, Width: 2, Height: 2.5},
+			pages: []*polygon.Shapes{s},
+			want: `%!PS-Adobe-3.0 EPSF-3.0
+%%BoundingBox: 0 0 144 180
+%%HiResBoundingBox: 0 0 144 180
+%%Pages: 1
+%%EndComments
+%%Page: 1 1
+newpath
+0 0 moveto
+144 0 lineto
+144 144 lineto
+0 144 lineto
+closepath
+36 36 moveto
+36 108 lineto
+108 108 lineto
+108 36 lineto
+closepath
+0 setgray eofill
+showpage
+%%Trailer
+%%EOF
+`,
+		},
+		{
+			cfg:   &Config{Width: 25.4, Height: 12.7, Origin: polygon.Point{1, 0}, Scale: 2, Proof: true, ProofWidth: 0.254},
+			pages: []*polygon.Shapes{nil, s},
+			want: `%!PS-Adobe-3.0
+%%BoundingBox: 0 0 72 36
+%%HiResBoundingBox: 0 0 72 36
+%%Pages: 2
+%%EndComments
+%%BeginSetup
+<< /PageSize [72 36] >> setpagedevice
+%%EndSetup
+%%Page: 1 1
+showpage
+%%Page: 2 2
+newpath
+-5.669 0 moveto
+5.669 0 lineto
+5.669 11.339 lineto
+-5.669 11.339 lineto
+closepath
+-2.835 2.835 moveto
+-2.835 8.504 lineto
+2.835 8.504 lineto
+2.835 2.835 lineto
+closepath
+0 setgray 0.72 setlinewidth 1 setlinejoin stroke
+showpage
+%%Trailer
+%%EOF
+`,
+		},
+	}
+	for i, v := range ts {
+		var b bytes.Buffer
+		if err := Write(&b, v.cfg, v.pages...); err != nil {
+			t.Fatalf("[%d] write failed: %v", i, err)
+		}
+		if got := b.String(); got != v.want {
+			t.Errorf("[%d] got:\n%s\nwant:\n%s", i, got, v.want)
+		}
+	}
+
+	// Without pages, a default document is a single blank page.
+	var b bytes.Buffer
+	if err := Write(&b, &Config{Inches: true}); err != nil {
+		t.Fatalf("letter write failed: %v", err)
+	}
+	for _, want := range []string{
+		"%%BoundingBox: 0 0 612 792\n",
+		"%%Pages: 1\n",
+		"<< /PageSize [612 792] >> setpagedevice\n",
+		"%%Page: 1 1\nshowpage\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("letter document does not contain %q:\n%s", want, b.String())
+		}
+	}
+	if err := Write(&bytes.Buffer{}, &Config{EPS: true}, s, s); err != ErrPages {
+		t.Errorf("two page EPS got err=%v, want %v", err, ErrPages)
+	}
+}
@@ -0,0 +1,142 @@
+package polymark
+
+import (
+	"math"
+
+	"zappem.net/pub/math/polygon"
+)
+
+// Rect constructs a filled rectangle polygon with opposite corners
+// at a and b.
+func (pen *Pen) Rect(s *polygon.Shapes, a, b polygon.Point) *polygon.Shapes {
+	ll, tr := polygon.BB(a, b)
+	return s.Builder(
+		ll,
+		polygon.Point{X: tr.X, Y: ll.Y},
+		tr,
+		polygon.Point{X: ll.X, Y: tr.Y},
+	)
+}
+
+// RectOutline constructs the outline of a rectangle with opposite
+// corners at a and b. The edges of the rectangle are rendered as
+// lines of the specified width, and the corners are joined in the
+// pen.Join style, as per (*Pen).Loop.
+func (pen *Pen) RectOutline(s *polygon.Shapes, a, b polygon.Point, width float64) *polygon.Shapes {
+	ll, tr := polygon.BB(a, b)
+	return pen.Loop(s, []polygon.Point{
+		ll,
+		{X: tr.X, Y: ll.Y},
+		tr,
+		{X: ll.X, Y: tr.Y},
+	}, width)
+}
+
+// corner appends to pts the points of a quarter circle of radius r
+// around c starting at angle theta and sweeping counter-clockwise. A
+// radius of zero (or less) appends just c.
+func (pen *Pen) corner(pts []polygon.Point, c polygon.Point, r, theta float64) []polygon.Point {
+	if r <= 0 {
+		return append(pts, c)
+	}
+	pts = append(pts, polygon.Point{X: c.X + r*math.Cos(theta), Y: c.Y + r*math.Sin(theta)})
+	pts = pen.arc(pts, c, r, theta, math.Pi/2)
+	return append(pts, polygon.Point{X: c.X + r*math.Cos(theta+math.Pi/2), Y: c.Y + r*math.Sin(theta+math.Pi/2)})
+}
+
+// roundedRect returns the counter-clockwise points of a rectangle
+// with opposite corners at a and b, and corners rounded with the
+// radii of the lower-left, lower-right, upper-right and upper-left
+// corners respectively. Radii that are too large to fit are scaled
+// down proportionally.
+func (pen *Pen) roundedRect(a, b polygon.Point, radii [4]float64) []polygon.Point {
+	ll, tr := polygon.BB(a, b)
+	w, h := tr.X-ll.X, tr.Y-ll.Y
+	f := 1.0
+	for _, v := range [][3]float64{
+		{w, radii[0], radii[1]},
+		{w, radii[3], radii[2]},
+		{h, radii[0], radii[3]},
+		{h, radii[1], radii[2]},
+	} {
+		if sum := v[1] + v[2]; sum > 0 && v[0] < f*sum {
+			f = v[0] / sum
+		}
+	}
+	for i := range radii {
+		radii[i] *= f
+	}
+	var pts []polygon.Point
+	pts = pen.corner(pts, polygon.Point{X: ll.X + radii[0], Y: ll.Y + radii[0]}, radii[0], math.Pi)
+	pts = pen.corner(pts, polygon.Point{X: tr.X - radii[1], Y: ll.Y + radii[1]}, radii[1], 1.5*math.Pi)
+	pts = pen.corner(pts, polygon.Point{X: tr.X - radii[2], Y: tr.Y - radii[2]}, radii[2], 0)
+	pts = pen.corner(pts, polygon.Point{X: ll.X + radii[3], Y: tr.Y - radii[3]}, radii[3], math.Pi/2)
+	return closed(pts)
+}
+
+// RoundedRect constructs a filled rectangle polygon with opposite
+// corners at a and b. The corners are rounded with the radii of the
+// lower-left, lower-right, upper-right and upper-left corners
+// respectively. Radii that are too large to fit the rectangle are
+// scaled down proportionally. A rectangle that collapses to fewer
+// than 3 distinct points is ignored.
+func (pen *Pen) RoundedRect(s *polygon.Shapes, a, b polygon.Point, radii [4]float64) *polygon.Shapes {
+	pts := pen.roundedRect(a, b, radii)
+	if len(pts) < 3 {
+		return s
+	}
+	return s.Builder(pts...)
+}
+
+// RoundedRectOutline constructs the outline of a rounded rectangle,
+// as per (*Pen).RoundedRect, with edges rendered as lines of the
+// specified width.
+func (pen *Pen) RoundedRectOutline(s *polygon.Shapes, a, b polygon.Point, radii [4]float64, width float64) *polygon.Shapes {
+	return pen.Loop(s, pen.roundedRect(a, b, radii), width)
+}
+
+// ellipse returns the points of an elliptical arc around c, with
+// radii rx and ry, rotated counter-clockwise by rotation. The arc
+// starts at the parametric angle start and sweeps by sweep radians
+// (+ve = counter-clockwise). The returned points include both ends of
+// the arc. The density of the points is that of a (*Pen).Circle of
+// the larger radius.
+func (pen *Pen) ellipse(c polygon.Point, rx, ry, rotation, start, sweep float64) []polygon.Point {
+	r := math.Max(math.Abs(rx), math.Abs(ry))
+	m := math.Ceil(math.Abs(sweep) * pen.segments(r) / twoPi)
+	if m < 1 {
+		m = 1
+	}
+	sR, cR := math.Sincos(rotation)
+	var pts []polygon.Point
+	for i := 0.0; i <= m; i++ {
+		sT, cT := math.Sincos(start + sweep*i/m)
+		x, y := rx*cT, ry*sT
+		pts = append(pts, polygon.Point{
+			X: c.X + x*cR - y*sR,
+			Y: c.Y + x*sR + y*cR,
+		})
+	}
+	return pts
+}
+
+// Ellipse constructs an approximate ellipse polygon centered at c,
+// with radii rx and ry along its axes, rotated counter-clockwise by
+// rotation radians.
+func (pen *Pen) Ellipse(s *polygon.Shapes, c polygon.Point, rx, ry, rotation float64) *polygon.Shapes {
+	pts := pen.ellipse(c, rx, ry, rotation, 0, twoPi)
+	return s.Builder(pts[:len(pts)-1]...)
+}
+
+// EllipseArc constructs the outline of a line of the specified width
+// that follows an elliptical arc. The ellipse is centered at c, with
+// radii rx and ry along its axes, and is rotated counter-clockwise by
+// rotation radians. The arc runs from angle start to angle end,
+// counter-clockwise if end > start and clockwise otherwise. These
+// angles are those of the parametric form of the unrotated ellipse,
+// (rx*cos(angle), ry*sin(angle)), which only coincide with polar
+// angles for circles. The arc is rendered with (*Pen).Stroke and the
+// ends of it are capped as indicated by caps.
+func (pen *Pen) EllipseArc(s *polygon.Shapes, c polygon.Point, rx, ry, rotation, start, end, width float64, caps Caps) *polygon.Shapes {
+	return pen.Stroke(s, pen.ellipse(c, rx, ry, rotation, start, end-start), width, caps)
+}
@@ -0,0 +1,99 @@
+package polymark
+
+import (
+	"math"
+	"testing"
+
+	"zappem.net/pub/math/polygon"
+)
+
+func TestRect(t *testing.T) {
+	pen := &Pen{Scribe: 1}
+	a, b := polygon.Point{10, 0}, polygon.Point{0, 5}
+	s := pen.Rect(nil, a, b)
+	s = pen.RectOutline(s, a, b, 2)
+	s = pen.RoundedRect(s, a, b, [4]float64{0, 1, 2, 3})
+	s = pen.RoundedRect(s, a, b, [4]float64{10, 10, 10, 10})
+	s = pen.RoundedRectOutline(s, a, b, [4]float64{2, 2, 2, 2}, 2)
+	ts := []struct {
+		hole   bool
+		n      int
+		ll, tr polygon.Point
+	}{
+		{false, 4, polygon.Point{0, 0}, polygon.Point{10, 5}},
+		{false, 20, polygon.Point{-1, -1}, polygon.Point{11, 6}},
+		{true, 4, polygon.Point{1, 1}, polygon.Point{9, 4}},
+		{false, 28, polygon.Point{0, 0}, polygon.Point{10, 5}},
+		{false, 42, polygon.Point{0, 0}, polygon.Point{10, 5}},
+		{false, 72, polygon.Point{-1, -1}, polygon.Point{11, 6}},
+		{true, 36, polygon.Point{1, 1}, polygon.Point{9, 4}},
+	}
+	if len(s.P) != len(ts) {
+		t.Fatalf("got %d polygons, want %d", len(s.P), len(ts))
+	}
+	for i, v := range ts {
+		p := s.P[i]
+		if p.Hole != v.hole {
+			t.Errorf("[%d] got hole=%v, want %v", i, p.Hole, v.hole)
+		}
+		if len(p.PS) != v.n {
+			t.Errorf("[%d] got %d points, want %d: %v", i, len(p.PS), v.n, p.PS)
+		}
+		if ll, tr := p.BB(); !polygon.MatchPoint(ll, v.ll) || !polygon.MatchPoint(tr, v.tr) {
+			t.Errorf("[%d] got BB=%v,%v want %v,%v", i, ll, tr, v.ll, v.tr)
+		}
+	}
+	for _, b := range []polygon.Point{a, {10, 3}, {7, 0}} {
+		if s := pen.RoundedRect(nil, a, b, [4]float64{}); s != nil {
+			t.Errorf("degenerate %v,%v rect got %v", a, b, s.P)
+		}
+		if s := pen.RoundedRectOutline(nil, a, b, [4]float64{}, 1); s != nil {
+			t.Errorf("degenerate %v,%v outline got %v", a, b, s.P)
+		}
+	}
+}
+
+func TestEllipse(t *testing.T) {
+	pen := &Pen{Scribe: 1}
+	c := polygon.Point{5, 5}
+	s := pen.Ellipse(nil, c, 4, 2, 0)
+	s = pen.Ellipse(s, c, 4, 2, math.Pi/2)
+	s = pen.Ellipse(s, c, 2, 2, 0)
+	s = pen.EllipseArc(s, c, 4, 2, 0, 0, math.Pi, 2, Caps{})
+	s = pen.EllipseArc(s, c, 4, 2, 0, 0, -math.Pi, 2, Caps{})
+	ts := []struct {
+		n      int
+		ll, tr polygon.Point
+	}{
+		{64, polygon.Point{1, 3}, polygon.Point{9, 7}},
+		{64, polygon.Point{3, 1}, polygon.Point{7, 9}},
+		{32, polygon.Point{3, 3}, polygon.Point{7, 7}},
+		{97, polygon.Point{0, 5}, polygon.Point{10, 8}},
+		{97, polygon.Point{0, 2}, polygon.Point{10, 5}},
+	}
+	if len(s.P) != len(ts) {
+		t.Fatalf("got %d polygons, want %d", len(s.P), len(ts))
+	}
+	circle := pen.Circle(nil, c, 2)
+	for i, pt := range s.P[2].PS {
+		if !polygon.MatchPoint(pt, circle.P[0].PS[i]) {
+			t.Errorf("circular ellipse [%d] got %v, want %v", i, pt, circle.P[0].PS[i])
+		}
+	}
+	for i, v := range ts {
+		p := s.P[i]
+		if p.Hole {
+			t.Errorf("[%d] is a hole", i)
+		}
+		if len(p.PS) != v.n {
+			t.Errorf("[%d] got %d points, want %d", i, len(p.PS), v.n)
+		}
+		// The butt ends of the arcs are offset along the normal
+		// of the first and last segments, so are not exactly
+		// horizontal.
+		ll, tr := p.BB()
+		if d := math.Max(math.Max(math.Abs(ll.X-v.ll.X), math.Abs(ll.Y-v.ll.Y)), math.Max(math.Abs(tr.X-v.tr.X), math.Abs(tr.Y-v.tr.Y))); d > 0.1 {
+			t.Errorf("[%d] got BB=%v,%v want %v,%v", i, ll, tr, v.ll, v.tr)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package polymark
+
+import (
+	"math"
+
+	"zappem.net/pub/math/polygon"
+)
+
+// JoinStyle selects how the outline of a line is constructed at the
+// points where consecutive segments of the line meet.
+type JoinStyle int
+
+// JoinOverlap outlines each segment separately, and optionally caps
+// the corners with circles. JoinMiter extends the outer edges of the
+// segments to meet at a point. JoinBevel cuts the outer corner off
+// with a straight edge. JoinRound follows a circular arc around the
+// outer corner.
+const (
+	JoinOverlap JoinStyle = iota
+	JoinMiter
+	JoinBevel
+	JoinRound
+)
+
+// straight is the angular tolerance (radians) below which
+// consecutive segments are treated as collinear.
+const straight = 1e-9
+
+// right returns the unit vector perpendicular to, and to the right
+// of, the direction from a to b.
+func right(a, b polygon.Point) polygon.Point {
+	u, _ := a.Unit(b)
+	return polygon.Point{X: u.Y, Y: -u.X}
+}
+
+// crossing computes where the line a-b intersects the line c-d. The
+// hit value is only true if the intersection falls within both of
+// the line segments.
+func crossing(a, b, c, d polygon.Point) (hit bool, at polygon.Point) {
+	dX1, dY1 := b.X-a.X, b.Y-a.Y
+	dX2, dY2 := d.X-c.X, d.Y-c.Y
+	den := dX1*dY2 - dY1*dX2
+	if math.Abs(den) < polygon.Zeroish {
+		return
+	}
+	t := ((c.X-a.X)*dY2 - (c.Y-a.Y)*dX2) / den
+	u := ((c.X-a.X)*dY1 - (c.Y-a.Y)*dX1) / den
+	if t < 0 || t > 1 || u < 0 || u > 1 {
+		return
+	}
+	return true, a.AddX(polygon.Point{X: dX1, Y: dY1}, t)
+}
+
+// arc appends to pts the points strictly between the start and end
+// of a circular arc of radius r around c. The arc starts at angle
+// theta and sweeps counter-clockwise by sweep radians. The density
+// of points is the same as that used by (*Pen).Circle.
+func (pen *Pen) arc(pts []polygon.Point, c polygon.Point, r, theta, sweep float64) []polygon.Point {
+	m := math.Ceil(math.Abs(sweep) * pen.segments(r) / twoPi)
+	for i := 1.0; i < m; i++ {
+		ang := theta + sweep*i/m
+		pts = append(pts, polygon.Point{
+			X: c.X + r*math.Cos(ang),
+			Y: c.Y + r*math.Sin(ang),
+		})
+	}
+	return pts
+}
+
+// join appends to pts the points of the right hand side of the
+// outline, offset by half, of the corner at v between the segments
+// a-v and v-b.
+func (pen *Pen) join(pts []polygon.Point, a, v, b polygon.Point, half float64) []polygon.Point {
+	n1, n2 := right(a, v), right(v, b)
+	p1, p2 := v.AddX(n1, half), v.AddX(n2, half)
+	// n1 and n2 are rotated from the segment directions by the
+	// same angle, so they share the segment turning angle.
+	turn := math.Atan2(n1.X*n2.Y-n1.Y*n2.X, n1.Dot(n2))
+	if math.Abs(turn) < straight {
+		return append(pts, p1)
+	}
+	if turn < 0 && turn > straight-math.Pi {
+		// Inside corner, the offset segments overlap.
+		if hit, at := crossing(a.AddX(n1, half), p1, p2, b.AddX(n2, half)); hit {
+			return append(pts, at)
+		}
+		return append(pts, p1, v, p2)
+	}
+	if turn < 0 {
+		turn = math.Pi
+	}
+	switch pen.Join {
+	case JoinMiter:
+		limit := pen.MiterLimit
+		if limit < 1 {
+			limit = 4
+		}
+		// The miter ratio is 1/cos(turn/2), squared is 2/k.
+		if k := 1 + n1.Dot(n2); k > 0 && 2 < limit*limit*k {
+			return append(pts, v.AddX(n1.AddX(n2, 1), half/k))
+		}
+	case JoinBevel:
+	default:
+		pts = append(pts, p1)
+		pts = pen.arc(pts, v, half, math.Atan2(n1.Y, n1.X), turn)
+		return append(pts, p2)
+	}
+	return append(pts, p1, p2)
+}
+
+// side returns the right hand side of the outline, offset by half,
+// of the line through the points pts. The returned points include
+// those of the joins at each interior point.
+func (pen *Pen) side(pts []polygon.Point, half float64) []polygon.Point {
+	n := len(pts)
+	out := []polygon.Point{pts[0].AddX(right(pts[0], pts[1]), half)}
+	for i := 1; i < n-1; i++ {
+		out = pen.join(out, pts[i-1], pts[i], pts[i+1], half)
+	}
+	return append(out, pts[n-1].AddX(right(pts[n-2], pts[n-1]), half))
+}
+
+// distinct returns pts omitting any points that match their
+// predecessor.
+func distinct(pts []polygon.Point) []polygon.Point {
+	var out []polygon.Point
+	for i, pt := range pts {
+		if i != 0 && polygon.MatchPoint(out[len(out)-1], pt) {
+			continue
+		}
+		out = append(out, pt)
+	}
+	return out
+}
+
+// closed returns the distinct points of pts, omitting the last point
+// if it matches the first.
+func closed(pts []polygon.Point) []polygon.Point {
+	pts = distinct(pts)
+	if n := len(pts); n > 1 && polygon.MatchPoint(pts[0], pts[n-1]) {
+		pts = pts[:n-1]
+	}
+	return pts
+}
+
+// length returns the length of the line through pts.
+func length(pts []polygon.Point) (d float64) {
+	for i := 1; i < len(pts); i++ {
+		d += math.Hypot(pts[i].X-pts[i-1].X, pts[i].Y-pts[i-1].Y)
+	}
+	return
+}
+
+// shorten returns a copy of the line through pts with a length d
+// removed from its end.
+func shorten(pts []polygon.Point, d float64) []polygon.Point {
+	for i := len(pts) - 1; i > 0; i-- {
+		a, b := pts[i-1], pts[i]
+		l := math.Hypot(b.X-a.X, b.Y-a.Y)
+		if l > d {
+			out := append([]polygon.Point{}, pts[:i]...)
+			return append(out, a.AddX(b.AddX(a, -1), (l-d)/l))
+		}
+		d -= l
+	}
+	return pts[:1]
+}
+
+// reverse returns a reversed copy of pts.
+func reverse(pts []polygon.Point) []polygon.Point {
+	rev := make([]polygon.Point, len(pts))
+	for i, pt := range pts {
+		rev[len(pts)-1-i] = pt
+	}
+	return rev
+}
+
+// CapStyle selects how the end of a line is rendered.
+type CapStyle int
+
+// CapButt ends the line squarely at its end point. CapSquare extends
+// the line squarely beyond its end point by half of its width.
+// CapRound ends the line with a semicircle centered on its end
+// point. CapArrow ends the line with an arrowhead whose tip is at
+// the end point. CapTail cuts a swallowtail notch, half of the line
+// width deep, into the end of the line.
+const (
+	CapButt CapStyle = iota
+	CapSquare
+	CapRound
+	CapArrow
+	CapTail
+)
+
+// Caps holds the cap styles used at the start and end of a stroked
+// line.
+type Caps struct {
+	Start, End CapStyle
+
+	// ArrowLength and ArrowWidth are the dimensions of any
+	// CapArrow arrowheads. Values of zero imply 3 times the line
+	// width. An arrowhead is never longer than half of the line
+	// it caps, or a third of it when both ends are arrows, so that
+	// some of the line remains between them.
+	ArrowLength, ArrowWidth float64
+}
+
+// cap appends to pts the points of an end cap at b for the line a-b.
+// For CapArrow, b is the base of the arrowhead and tip is its tip.
+func (pen *Pen) cap(pts []polygon.Point, style CapStyle, a, b, tip polygon.Point, half, wide float64) []polygon.Point {
+	n := right(a, b)
+	d := polygon.Point{X: -n.Y, Y: n.X}
+	switch style {
+	case CapSquare:
+		return append(pts, b.AddX(n, half).AddX(d, half), b.AddX(n, -half).AddX(d, half))
+	case CapRound:
+		return pen.arc(pts, b, half, math.Atan2(n.Y, n.X), math.Pi)
+	case CapArrow:
+		n = right(b, tip)
+		return append(pts, b.AddX(n, wide), tip, b.AddX(n, -wide))
+	case CapTail:
+		return append(pts, b.AddX(d, -half))
+	}
+	return pts
+}
+
+// stroke appends to s a single polygon outlining the line through
+// pts, with a width of 2*half. The corners are joined in the
+// pen.Join style, and the ends of the line are capped according to
+// caps.
+func (pen *Pen) stroke(s *polygon.Shapes, pts []polygon.Point, half float64, caps Caps) *polygon.Shapes {
+	pts = distinct(pts)
+	if len(pts) == 0 {
+		return s
+	}
+	if len(pts) == 1 {
+		switch caps.End {
+		case CapRound:
+			s = pen.circle(s, pts[0], half, 0)
+		case CapSquare:
+			s = s.Builder(
+				polygon.Point{X: pts[0].X - half, Y: pts[0].Y - half},
+				polygon.Point{X: pts[0].X + half, Y: pts[0].Y - half},
+				polygon.Point{X: pts[0].X + half, Y: pts[0].Y + half},
+				polygon.Point{X: pts[0].X - half, Y: pts[0].Y + half},
+			)
+		}
+		return s
+	}
+	head, wide := caps.ArrowLength, caps.ArrowWidth/2
+	if head == 0 {
+		head = 6 * half
+	}
+	if wide == 0 {
+		wide = 3 * half
+	}
+	l := length(pts) / 2
+	if caps.Start == CapArrow && caps.End == CapArrow {
+		l = length(pts) / 3
+	}
+	if head > l {
+		head = l
+	}
+	start, end := pts[0], pts[len(pts)-1]
+	if caps.End == CapArrow {
+		pts = shorten(pts, head)
+	}
+	if caps.Start == CapArrow {
+		pts = reverse(shorten(reverse(pts), head))
+	}
+	n := len(pts)
+	rev := reverse(pts)
+	outline := pen.side(pts, half)
+	outline = pen.cap(outline, caps.End, pts[n-2], pts[n-1], end, half, wide)
+	outline = append(outline, pen.side(rev, half)...)
+	outline = pen.cap(outline, caps.Start, rev[n-2], rev[n-1], start, half, wide)
+	return s.Builder(outline...)
+}
+
+// Stroke constructs a single polygon outline of a line of the
+// specified width through the points pts. The corners of the line
+// are joined in the pen.Join style, where JoinOverlap is rendered as
+// JoinRound, and the ends of the line are capped as indicated by
+// caps. Outlines of lines that cross themselves, or that turn sharply
+// over distances shorter than their width, overlap themselves and
+// may need to be combined with (*polygon.Shapes).Union().
+func (pen *Pen) Stroke(s *polygon.Shapes, pts []polygon.Point, width float64, caps Caps) *polygon.Shapes {
+	return pen.stroke(s, pts, width/2, caps)
+}
+
+// ring returns the right hand side of the outline, offset by half,
+// of the closed loop through the points pts. It also reports whether
+// every offset segment runs in the same direction as the segment it
+// follows, which is not the case for an offset that is too large for
+// the inside of the loop, where the ring turns inside out.
+func (pen *Pen) ring(pts []polygon.Point, half float64) (out []polygon.Point, ok bool) {
+	n := len(pts)
+	ok = true
+	var last polygon.Point
+	for i, pt := range pts {
+		from := len(out)
+		out = pen.join(out, pts[(i+n-1)%n], pt, pts[(i+1)%n], half)
+		if i != 0 {
+			seg, off := pt.AddX(pts[i-1], -1), out[from].AddX(last, -1)
+			ok = ok && seg.Dot(off) >= 0
+		}
+		last = out[len(out)-1]
+	}
+	seg, off := pts[0].AddX(pts[n-1], -1), out[0].AddX(last, -1)
+	return out, ok && seg.Dot(off) >= 0
+}
+
+// Loop constructs the outline of a closed loop of straight line
+// segments of a specified width through the points pts. The last
+// point is implicitly joined to the first, and every corner is
+// joined in the pen.Join style, where JoinOverlap is rendered as
+// JoinRound. The outline is appended to s as an outer polygon
+// followed by the polygon of its inner hole. Lines that are too wide
+// for the loop to have a hole render just the filled outer polygon.
+// Loops with fewer than 3 distinct points are ignored.
+func (pen *Pen) Loop(s *polygon.Shapes, pts []polygon.Point, width float64) *polygon.Shapes {
+	pts = closed(pts)
+	if len(pts) < 3 {
+		return s
+	}
+	half := width / 2
+	a, aOK := pen.ring(pts, half)
+	b, bOK := pen.ring(reverse(pts), half)
+	if area(a) < 0 {
+		a, b, bOK = b, a, aOK
+	}
+	s = s.Builder(a...)
+	if !bOK || area(b) >= 0 {
+		// The inner ring turned inside out, so the line
+		// covers the whole interior.
+		return s
+	}
+	return s.Builder(b...)
+}
+
+// area returns the signed area enclosed by the polygon pts. This is
+// positive for counter-clockwise polygons.
+func area(pts []polygon.Point) (a float64) {
+	for i, pt := range pts {
+		prev := pts[(i+len(pts)-1)%len(pts)]
+		a += prev.X*pt.Y - pt.X*prev.Y
+	}
+	return a / 2
+}
@@ -0,0 +1,165 @@
+package polymark
+
+import (
+	"testing"
+
+	"zappem.net/pub/math/polygon"
+)
+
+func TestJoin(t *testing.T) {
+	corner := []polygon.Point{{0, 0}, {10, 0}, {10, 10}}
+	ts := []struct {
+		join  JoinStyle
+		limit float64
+		pts   []polygon.Point
+		n     int
+		ll    polygon.Point
+		tr    polygon.Point
+	}{
+		{JoinMiter, 0, corner, 6, polygon.Point{0, -1}, polygon.Point{11, 10}},
+		{JoinBevel, 0, corner, 7, polygon.Point{0, -1}, polygon.Point{11, 10}},
+		{JoinRound, 0, corner, 10, polygon.Point{0, -1}, polygon.Point{11, 10}},
+		{JoinMiter, 0, []polygon.Point{{0, 0}, {10, 0}, {0, 2}}, 9, polygon.Point{-0.196116, -1}, polygon.Point{10.196116, 2.980581}},
+		{JoinMiter, 20, []polygon.Point{{0, 0}, {10, 0}, {0, 2}}, 8, polygon.Point{-0.196116, -1}, polygon.Point{20.099020, 2.980581}},
+	}
+	for i, v := range ts {
+		pen := &Pen{Scribe: 1, Join: v.join, MiterLimit: v.limit}
+		s := pen.Line(nil, v.pts, 2, true, false)
+		if len(s.P) != 1 {
+			t.Fatalf("[%d] got %d polygons, want 1", i, len(s.P))
+		}
+		if p := s.P[0]; p.Hole {
+			t.Errorf("[%d] outline is a hole: %v", i, p.PS)
+		} else if len(p.PS) != v.n {
+			t.Errorf("[%d] got %d points, want %d: %v", i, len(p.PS), v.n, p.PS)
+		}
+		ll, tr := s.BB()
+		if !polygon.MatchPoint(ll, v.ll) || !polygon.MatchPoint(tr, v.tr) {
+			t.Errorf("[%d] got BB=%v,%v want %v,%v", i, ll, tr, v.ll, v.tr)
+		}
+	}
+}
+
+func TestJoinRound(t *testing.T) {
+	pen := &Pen{Scribe: 1, Join: JoinRound}
+	s := pen.Line(nil, []polygon.Point{
+		{1, 1},
+		{10, 8},
+		{20, 1},
+		{30, 8},
+	}, 3, false, true)
+	if len(s.P) != 1 {
+		t.Fatalf("got %d polygons, want 1", len(s.P))
+	}
+	got := display(s)
+	want := []string{
+		".##.................##...........",
+		"#..#...............#..##.........",
+		"#...##...........##.....#........",
+		".#....#.........#...#....#.......",
+		"..#....#.......#...#.##...##.....",
+		"...##...##...##..##....#....#....",
+		".....#....#.#...#.......##...##..",
+		"......#....#...#..........#....#.",
+		".......##....##............#....#",
+		".........#..#...............##..#",
+		"..........##..................##.",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("incorrect number of lines got=%d want=%d", len(got), len(want))
+	}
+	for i, line := range got {
+		t.Logf("[%2d]  got=%q", i, line)
+		if line != want[i] {
+			t.Errorf("[%2d] want=%q", i, want[i])
+		}
+	}
+}
+
+func TestCaps(t *testing.T) {
+	ts := []struct {
+		caps Caps
+		n    int
+		ll   polygon.Point
+		tr   polygon.Point
+	}{
+		{Caps{}, 4, polygon.Point{0, -1}, polygon.Point{10, 1}},
+		{Caps{Start: CapSquare, End: CapSquare}, 8, polygon.Point{-1, -1}, polygon.Point{11, 1}},
+		{Caps{Start: CapRound, End: CapRound}, 18, polygon.Point{-1, -1}, polygon.Point{11, 1}},
+		{Caps{End: CapArrow}, 7, polygon.Point{0, -3}, polygon.Point{10, 3}},
+		{Caps{Start: CapArrow, End: CapArrow, ArrowLength: 2, ArrowWidth: 4}, 10, polygon.Point{0, -2}, polygon.Point{10, 2}},
+		{Caps{Start: CapTail}, 5, polygon.Point{0, -1}, polygon.Point{10, 1}},
+		// The default arrowheads are too long for both ends.
+		{Caps{Start: CapArrow, End: CapArrow}, 10, polygon.Point{0, -3}, polygon.Point{10, 3}},
+		{Caps{Start: CapArrow, End: CapArrow, ArrowLength: 5}, 10, polygon.Point{0, -3}, polygon.Point{10, 3}},
+	}
+	pen := &Pen{Scribe: 1}
+	for i, v := range ts {
+		s := pen.Stroke(nil, []polygon.Point{{0, 0}, {10, 0}}, 2, v.caps)
+		if len(s.P) != 1 {
+			t.Fatalf("[%d] got %d polygons, want 1", i, len(s.P))
+		}
+		if p := s.P[0]; p.Hole {
+			t.Errorf("[%d] outline is a hole: %v", i, p.PS)
+		} else if len(p.PS) != v.n {
+			t.Errorf("[%d] got %d points, want %d: %v", i, len(p.PS), v.n, p.PS)
+		}
+		ll, tr := s.BB()
+		if !polygon.MatchPoint(ll, v.ll) || !polygon.MatchPoint(tr, v.tr) {
+			t.Errorf("[%d] got BB=%v,%v want %v,%v", i, ll, tr, v.ll, v.tr)
+		}
+	}
+}
+
+func TestLoop(t *testing.T) {
+	square := []polygon.Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+	ts := []struct {
+		join JoinStyle
+		pts  []polygon.Point
+		n    int
+	}{
+		{JoinMiter, square, 4},
+		{JoinMiter, reverse(square), 4},
+		{JoinMiter, append(square, square[0]), 4},
+		{JoinBevel, square, 8},
+		{JoinOverlap, square, 20},
+	}
+	for i, v := range ts {
+		pen := &Pen{Scribe: 1, Join: v.join}
+		s := pen.Loop(nil, v.pts, 2)
+		if len(s.P) != 2 {
+			t.Fatalf("[%d] got %d polygons, want 2", i, len(s.P))
+		}
+		outer, inner := s.P[0], s.P[1]
+		if outer.Hole || !inner.Hole {
+			t.Errorf("[%d] got outer.Hole=%v inner.Hole=%v", i, outer.Hole, inner.Hole)
+		}
+		if len(outer.PS) != v.n || len(inner.PS) != 4 {
+			t.Errorf("[%d] got %d,%d points, want %d,4", i, len(outer.PS), len(inner.PS), v.n)
+		}
+		if ll, tr := outer.BB(); !polygon.MatchPoint(ll, polygon.Point{-1, -1}) || !polygon.MatchPoint(tr, polygon.Point{11, 11}) {
+			t.Errorf("[%d] got outer BB=%v,%v", i, ll, tr)
+		}
+		if ll, tr := inner.BB(); !polygon.MatchPoint(ll, polygon.Point{1, 1}) || !polygon.MatchPoint(tr, polygon.Point{9, 9}) {
+			t.Errorf("[%d] got inner BB=%v,%v", i, ll, tr)
+		}
+	}
+	pen := &Pen{Scribe: 1}
+	for _, join := range []JoinStyle{JoinMiter, JoinBevel, JoinRound} {
+		pen.Join = join
+		for _, width := range []float64{10, 12, 30} {
+			s := pen.RectOutline(nil, polygon.Point{0, 0}, polygon.Point{10, 10}, width)
+			if len(s.P) != 1 || s.P[0].Hole {
+				t.Errorf("join=%v width=%g: got %v, want one filled polygon", join, width, s.P)
+			}
+		}
+		s := pen.RectOutline(nil, polygon.Point{0, 0}, polygon.Point{10, 1}, 2)
+		if len(s.P) != 1 || s.P[0].Hole {
+			t.Errorf("join=%v thin rect: got %v, want one filled polygon", join, s.P)
+		}
+	}
+	pen.Join = JoinOverlap
+	if s := pen.Loop(nil, square[:2], 2); s != nil {
+		t.Errorf("two point loop generated %d polygons", len(s.P))
+	}
+}
@@ -0,0 +1,146 @@
+package polymark
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"zappem.net/pub/math/polygon"
+)
+
+// SVGOptions holds the rendering options for WriteSVG.
+type SVGOptions struct {
+	// Colors holds the colors used to render each layer. If there
+	// are more layers than colors, the colors are reused in
+	// order. The default is "black".
+	Colors []string
+
+	// Fill fills the shapes of each layer using the "evenodd"
+	// fill-rule, so holes are left unfilled.
+	Fill bool
+
+	// Outline, if non-zero, is the width of lines drawn along the
+	// outline of every polygon.
+	Outline float64
+
+	// Hatch, if non-zero, is the separation of the hatch lines
+	// that fill the shapes of each layer. These lines are at an
+	// angle of HatchAngle radians counter-clockwise from the
+	// horizontal axis, and are Scribe wide. A Scribe of zero
+	// implies Hatch/2.
+	Hatch, HatchAngle, Scribe float64
+
+	// FlipY negates the Y coordinates of the shapes. The polygon
+	// package has Y increasing up the page, SVG has it increasing
+	// down the page, so this renders shapes upright. It has the
+	// same effect on rendered text as the Pen.Reflect attribute.
+	FlipY bool
+
+	// Margin is added to all sides of the bounding box of the
+	// layers to form the viewBox of the SVG.
+	Margin float64
+
+	// Units is appended to the width and height attributes of the
+	// SVG, for example "mm".
+	Units string
+}
+
+// svgNum formats a coordinate value for SVG output.
+func svgNum(v float64) string {
+	n := fmt.Sprintf("%.3f", v)
+	if n == "-0.000" {
+		return "0.000"
+	}
+	return n
+}
+
+// svgPath returns the SVG path data for the polygons in s.
+func svgPath(s *polygon.Shapes, yScale float64) string {
+	var d []string
+	for _, p := range s.P {
+		for i, pt := range p.PS {
+			op := "L"
+			if i == 0 {
+				op = "M"
+			}
+			d = append(d, fmt.Sprint(op, svgNum(pt.X), ",", svgNum(yScale*pt.Y)))
+		}
+		d = append(d, "Z")
+	}
+	return strings.Join(d, " ")
+}
+
+// WriteSVG writes an SVG image of the layers of polygon shapes to w.
+// The viewBox of the image is derived from the combined bounding box
+// of the layers. A nil opts renders the layers filled.
+func WriteSVG(w io.Writer, layers []*polygon.Shapes, opts *SVGOptions) error {
+	if opts == nil {
+		opts = &SVGOptions{Fill: true}
+	}
+	yScale := 1.0
+	if opts.FlipY {
+		yScale = -1
+	}
+	var ll, tr polygon.Point
+	first := true
+	for _, s := range layers {
+		if s == nil || len(s.P) == 0 {
+			continue
+		}
+		l, t := s.BB()
+		if first {
+			ll, tr = l, t
+			first = false
+		} else {
+			ll, _ = polygon.BB(ll, l)
+			_, tr = polygon.BB(tr, t)
+		}
+	}
+	ll, tr = polygon.BB(polygon.Point{X: ll.X, Y: yScale * ll.Y}, polygon.Point{X: tr.X, Y: yScale * tr.Y})
+	ll = ll.AddX(polygon.Point{X: 1, Y: 1}, -opts.Margin)
+	tr = tr.AddX(polygon.Point{X: 1, Y: 1}, opts.Margin)
+	wide, high := tr.X-ll.X, tr.Y-ll.Y
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "<?xml version=\"1.0\"?>\n")
+	fmt.Fprintf(b, "<svg width=\"%.3f%s\" height=\"%.3f%s\"\n", wide, opts.Units, high, opts.Units)
+	fmt.Fprintf(b, "     viewBox=\"%s %s %s %s\"\n", svgNum(ll.X), svgNum(ll.Y), svgNum(wide), svgNum(high))
+	fmt.Fprintf(b, "     xmlns=\"http://www.w3.org/2000/svg\">\n")
+	for i, s := range layers {
+		col := "black"
+		if len(opts.Colors) != 0 {
+			col = opts.Colors[i%len(opts.Colors)]
+		}
+		fmt.Fprintf(b, "<g id=\"layer%d\">\n", i)
+		if s != nil && len(s.P) != 0 {
+			d := svgPath(s, yScale)
+			if opts.Fill {
+				fmt.Fprintf(b, "<path fill=\"%s\" fill-rule=\"evenodd\" stroke=\"none\" d=\"%s\"/>\n", col, d)
+			}
+			if opts.Outline != 0 {
+				fmt.Fprintf(b, "<path fill=\"none\" stroke=\"%s\" stroke-width=\"%.3f\" d=\"%s\"/>\n", col, opts.Outline, d)
+			}
+			if opts.Hatch != 0 {
+				scribe := opts.Scribe
+				if scribe == 0 {
+					scribe = opts.Hatch / 2
+				}
+				lines, err := Hatch(s, scribe, opts.Hatch, opts.HatchAngle)
+				if err != nil {
+					return err
+				}
+				var h []string
+				for _, line := range lines {
+					h = append(h, fmt.Sprint("M", svgNum(line.From.X), ",", svgNum(yScale*line.From.Y), " L", svgNum(line.To.X), ",", svgNum(yScale*line.To.Y)))
+				}
+				if len(h) != 0 {
+					fmt.Fprintf(b, "<path fill=\"none\" stroke=\"%s\" stroke-width=\"%.3f\" stroke-linecap=\"round\" d=\"%s\"/>\n", col, scribe, strings.Join(h, " "))
+				}
+			}
+		}
+		fmt.Fprintf(b, "</g>\n")
+	}
+	fmt.Fprintf(b, "</svg>\n")
+	return b.Flush()
+}
@@ -0,0 +1,65 @@
+package polymark
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"zappem.net/pub/math/polygon"
+)
+
+func TestWriteSVG(t *testing.T) {
+	pen := &Pen{Scribe: 1}
+	s := pen.Rect(nil, polygon.Point{0, 0}, polygon.Point{10, 10})
+	s = s.Builder(polygon.Point{2, 2}, polygon.Point{2, 8}, polygon.Point{8, 8}, polygon.Point{8, 2})
+	u := pen.Rect(nil, polygon.Point{20, 0}, polygon.Point{30, 5})
+
+	var b bytes.Buffer
+	if err := WriteSVG(&b, []*polygon.Shapes{s, u}, nil); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	want := `<?xml version="1.0"?>
+<svg width="30.000" height="10.000"
+     viewBox="0.000 0.000 30.000 10.000"
+     xmlns="http://www.w3.org/2000/svg">
+<g id="layer0">
+<path fill="black" fill-rule="evenodd" stroke="none" d="M0.000,0.000 L10.000,0.000 L10.000,10.000 L0.000,10.000 Z M2.000,2.000 L2.000,8.000 L8.000,8.000 L8.000,2.000 Z"/>
+</g>
+<g id="layer1">
+<path fill="black" fill-rule="evenodd" stroke="none" d="M20.000,0.000 L30.000,0.000 L30.000,5.000 L20.000,5.000 Z"/>
+</g>
+</svg>
+`
+	if got := b.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	b.Reset()
+	if err := WriteSVG(&b, []*polygon.Shapes{s, u}, &SVGOptions{
+		Colors:  []string{"red"},
+		Outline: .5,
+		Hatch:   1,
+		FlipY:   true,
+		Margin:  1,
+		Units:   "mm",
+	}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	got := b.String()
+	for _, w := range []string{
+		`<svg width="32.000mm" height="12.000mm"`,
+		`viewBox="-1.000 -11.000 32.000 12.000"`,
+		`<path fill="none" stroke="red" stroke-width="0.500" d="M20.000,0.000 L30.000,0.000 L30.000,-5.000 L20.000,-5.000 Z"/>`,
+		`stroke-linecap="round" d="M`,
+	} {
+		if !strings.Contains(got, w) {
+			t.Errorf("missing %q in:\n%s", w, got)
+		}
+	}
+	if strings.Contains(got, "fill-rule") {
+		t.Errorf("unexpected fill in:\n%s", got)
+	}
+	if n := strings.Count(got, "stroke-linecap"); n != 2 {
+		t.Errorf("got %d hatch paths, want 2", n)
+	}
+}
@@ -0,0 +1,562 @@
+package polymark
+
+import (
+	"errors"
+	"math"
+	"strings"
+
+	"zappem.net/pub/graphics/hershey"
+	"zappem.net/pub/math/polygon"
+)
+
+// ErrOverflow indicates that text does not fit in the space
+// provided for it.
+var ErrOverflow = errors.New("text overflows")
+
+// softHyphen marks a point in a word where it may be hyphenated.
+const softHyphen = "\u00ad"
+
+// lines splits text into lines at each newline, ignoring any
+// carriage returns that precede them.
+func lines(text string) []string {
+	ls := strings.Split(text, "\n")
+	for i, l := range ls {
+		ls[i] = strings.TrimSuffix(l, "\r")
+	}
+	return ls
+}
+
+// extent returns the combined Top and Bottom extent of the glyphs of
+// the lines, ls.
+func extent(font *hershey.Font, ls []string) (top, bottom int) {
+	for i, l := range ls {
+		gl, _, _ := font.Text(l)
+		if i == 0 || gl.Top < top {
+			top = gl.Top
+		}
+		if i == 0 || gl.Bottom > bottom {
+			bottom = gl.Bottom
+		}
+	}
+	return
+}
+
+// TextBlock renders text that may contain several lines, separated by
+// newlines, as a series of polygon outlines. The lines are spaced by
+// spacing times the combined Top to Bottom extent of the glyphs of
+// all of the lines, where a spacing of zero implies 1. Each line is
+// aligned horizontally relative to x, with the horizontal part of the
+// Alignment, as for (*Pen).Text. The vertical part of the Alignment
+// positions the block as a whole: AlignAbove places the top of the
+// first line at y, AlignBelow places the bottom of the last line at
+// y, and AlignMiddle places y midway between the middles of the first
+// and last lines. A single line block renders exactly as (*Pen).Text.
+func (pen *Pen) TextBlock(s *polygon.Shapes, x, y, scale, spacing float64, a Alignment, font *hershey.Font, text string) *polygon.Shapes {
+	return pen.TextBlockWith(s, x, y, scale, spacing, a, font, text, nil)
+}
+
+// unrotated returns a copy of opts, or of the zero TextOptions if
+// opts is nil, without any Angle.
+func unrotated(opts *TextOptions) *TextOptions {
+	o := &TextOptions{}
+	if opts != nil {
+		*o = *opts
+	}
+	o.Angle = 0
+	return o
+}
+
+// TextBlockWith renders text in the same way as (*Pen).TextBlock, but
+// with each line rendered by (*Pen).TextWith with the optional
+// parameters held in opts. The Angle of opts is ignored. A nil opts
+// is equivalent to (*Pen).TextBlock.
+func (pen *Pen) TextBlockWith(s *polygon.Shapes, x, y, scale, spacing float64, a Alignment, font *hershey.Font, text string, opts *TextOptions) *polygon.Shapes {
+	o := unrotated(opts)
+	ls := lines(text)
+	if spacing <= 0 {
+		spacing = 1
+	}
+	top, bottom := extent(font, ls)
+	_, yScale, _ := o.scales(pen, scale, font)
+	pitch := spacing * float64(bottom-top)
+	last := pitch * float64(len(ls)-1)
+	var y0 float64
+	switch a & ^3 {
+	case AlignAbove:
+		y0 = y - float64(top)*yScale
+	case AlignMiddle:
+		y0 = y - last/2*yScale
+	case AlignBelow:
+		y0 = y - (last+float64(bottom))*yScale
+	}
+	for i, l := range ls {
+		s = pen.TextWith(s, x, y0+float64(i)*pitch*yScale, scale, a&3, font, l, o)
+	}
+	return s
+}
+
+// advance returns the left edge and the width, in output units, of
+// the advance width of text laid out by spaced with the horizontal
+// scale, xScale, and stretched by opts.Stretch.
+func (opts *TextOptions) advance(font *hershey.Font, text string, xScale float64) (left, width float64) {
+	_, left, right := opts.spaced(font, text, xScale)
+	if opts.Stretch != 0 {
+		xScale *= opts.Stretch
+	}
+	return left * xScale, (right - left) * xScale
+}
+
+// wrap word-wraps text into lines with advance widths, as measured
+// by advance, no wider than width. Words are hyphenated at soft
+// hyphens when they do not fit. The ok value is false if some part of
+// a word is too wide for a line on its own.
+func wrap(text string, width float64, advance func(text string) float64) (ls []string, ok bool) {
+	ok = true
+	fits := func(text string) bool {
+		return advance(text) <= width
+	}
+	for _, para := range lines(text) {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			frags := strings.Split(word, softHyphen)
+			for len(frags) != 0 {
+				prefix := line
+				if prefix != "" {
+					prefix += " "
+				}
+				if whole := prefix + strings.Join(frags, ""); fits(whole) {
+					line = whole
+					break
+				}
+				k := len(frags) - 1
+				for k > 0 && !fits(prefix+strings.Join(frags[:k], "")+"-") {
+					k--
+				}
+				switch {
+				case k > 0:
+					ls = append(ls, prefix+strings.Join(frags[:k], "")+"-")
+					line, frags = "", frags[k:]
+				case line != "":
+					ls = append(ls, line)
+					line = ""
+				case len(frags) > 1:
+					ok = false
+					ls = append(ls, frags[0]+"-")
+					frags = frags[1:]
+				default:
+					ok = false
+					line, frags = frags[0], nil
+				}
+			}
+		}
+		ls = append(ls, line)
+	}
+	return
+}
+
+// Wrap word-wraps text, which may contain newlines to separate
+// paragraphs, into lines that are no wider than width when rendered
+// at scale. Lines are broken at spaces, and the widths are measured
+// with the advance widths of the glyphs. Words that contain soft
+// hyphens (U+00AD) may be broken at them, in which case the line
+// ends with a hyphen. All unused soft hyphens are removed. If some
+// part of a word cannot fit on a line by itself, the lines are
+// returned with an ErrOverflow error.
+func (pen *Pen) Wrap(scale, width float64, font *hershey.Font, text string) ([]string, error) {
+	return pen.WrapWith(scale, width, font, text, nil)
+}
+
+// WrapWith word-wraps text in the same way as (*Pen).Wrap, but
+// measures the lines as rendered by (*Pen).TextWith with the optional
+// parameters held in opts. A nil opts is equivalent to (*Pen).Wrap.
+func (pen *Pen) WrapWith(scale, width float64, font *hershey.Font, text string, opts *TextOptions) ([]string, error) {
+	o := unrotated(opts)
+	xScale, _, _ := o.scales(pen, scale, font)
+	ls, ok := wrap(text, width, func(text string) float64 {
+		_, w := o.advance(font, text, xScale)
+		return w
+	})
+	if !ok {
+		return ls, ErrOverflow
+	}
+	return ls, nil
+}
+
+// TextBox renders text word-wrapped, as by (*Pen).Wrap, into the box
+// with opposite corners a and b. The lines are spaced as for
+// (*Pen).TextBlock. The horizontal part of the Alignment aligns the
+// advance width of each line with the left, center or right of the
+// box. The vertical part aligns the block of lines with the top
+// (AlignAbove), middle (AlignMiddle) or bottom (AlignBelow) of the
+// box, where the top of the box is its edge nearest the top of the
+// glyphs, taking pen.Reflect into account. If the text does not fit
+// in the box at scale, and shrink is true, the largest scale, down to
+// 1, at which it fits is used instead. The scale used is returned. If
+// the text does not fit, nothing is rendered and ErrOverflow is
+// returned.
+func (pen *Pen) TextBox(s *polygon.Shapes, a, b polygon.Point, scale, spacing float64, align Alignment, shrink bool, font *hershey.Font, text string) (*polygon.Shapes, float64, error) {
+	return pen.TextBoxWith(s, a, b, scale, spacing, align, shrink, font, text, nil)
+}
+
+// TextBoxWith renders text in the same way as (*Pen).TextBox, but
+// with the lines wrapped by (*Pen).WrapWith and rendered by
+// (*Pen).TextWith with the optional parameters held in opts. The
+// Angle of opts is ignored. A nil opts is equivalent to
+// (*Pen).TextBox.
+func (pen *Pen) TextBoxWith(s *polygon.Shapes, a, b polygon.Point, scale, spacing float64, align Alignment, shrink bool, font *hershey.Font, text string, opts *TextOptions) (*polygon.Shapes, float64, error) {
+	o := unrotated(opts)
+	ll, tr := polygon.BB(a, b)
+	if spacing <= 0 {
+		spacing = 1
+	}
+	layout := func(scale float64) ([]string, float64, bool) {
+		ls, err := pen.WrapWith(scale, tr.X-ll.X, font, text, o)
+		_, yScale, _ := o.scales(pen, scale, font)
+		top, bottom := extent(font, ls)
+		high := spacing*float64(bottom-top)*float64(len(ls)-1) + float64(bottom-top)
+		return ls, scale, err == nil && high*math.Abs(yScale) <= tr.Y-ll.Y
+	}
+	ls, scale, ok := layout(scale)
+	if !ok && shrink && scale > 1 {
+		lo, hi := 1.0, scale
+		if ls, scale, ok = layout(lo); ok {
+			for i := 0; i < 40; i++ {
+				mid := (lo + hi) / 2
+				if _, _, fit := layout(mid); fit {
+					lo = mid
+				} else {
+					hi = mid
+				}
+			}
+			ls, scale, ok = layout(lo)
+		}
+	}
+	if !ok {
+		return s, scale, ErrOverflow
+	}
+
+	xScale, yScale, _ := o.scales(pen, scale, font)
+	top, bottom := extent(font, ls)
+	pitch := spacing * float64(bottom-top)
+	last := pitch * float64(len(ls)-1)
+	upper, lower := ll.Y, tr.Y
+	if pen.Reflect {
+		upper, lower = lower, upper
+	}
+	var y0 float64
+	switch align & ^3 {
+	case AlignAbove:
+		y0 = upper - float64(top)*yScale
+	case AlignMiddle:
+		y0 = (upper+lower)/2 - (float64(top)+last+float64(bottom))/2*yScale
+	case AlignBelow:
+		y0 = lower - (last+float64(bottom))*yScale
+	}
+	for i, l := range ls {
+		left, width := o.advance(font, l, xScale)
+		var x float64
+		switch align & 3 {
+		case AlignLeft:
+			x = ll.X - left
+		case AlignCenter:
+			x = (ll.X+tr.X)/2 - left - width/2
+		case AlignRight:
+			x = tr.X - left - width
+		}
+		s = pen.TextWith(s, x, y0+float64(i)*pitch*yScale, scale, AlignLeft, font, l, o)
+	}
+	return s, scale, nil
+}
+
+// TextMetrics holds the dimensions of some text as rendered by
+// (*Pen).Text, in output units.
+type TextMetrics struct {
+	// Left is the offset of the start of the advance width of the
+	// text from the x coordinate of AlignLeft text, and Advance is
+	// the advance width of the text.
+	Left, Advance float64
+
+	// Min and Max are the bottom left and top right corners of the
+	// bounding box of the ink of the text, rendered with AlignLeft
+	// and AlignMiddle at the origin. The ink includes the width of
+	// the rendered lines.
+	Min, Max polygon.Point
+
+	// Ascent and Descent are the distances from the y coordinate
+	// of AlignMiddle text to the top and bottom of the glyphs, as
+	// used by AlignAbove and AlignBelow.
+	Ascent, Descent float64
+}
+
+// MeasureText returns the dimensions of text rendered by (*Pen).Text
+// at scale, without rendering it. The Min and Max values of the ink
+// bounding box take pen.Reflect into account.
+func (pen *Pen) MeasureText(scale float64, font *hershey.Font, text string) TextMetrics {
+	return pen.MeasureTextWith(scale, font, text, nil)
+}
+
+// MeasureTextWith returns the dimensions of text rendered by
+// (*Pen).TextWith with the optional parameters held in opts, as for
+// (*Pen).MeasureText. The dimensions are those of the text before any
+// rotation by the Angle of opts. A nil opts is equivalent to
+// (*Pen).MeasureText.
+func (pen *Pen) MeasureTextWith(scale float64, font *hershey.Font, text string, opts *TextOptions) TextMetrics {
+	o := unrotated(opts)
+	gl, _, _ := font.Text(text)
+	xScale, yScale, wScale := o.scales(pen, scale, font)
+	strokes, _, _ := o.strokes(font, text, xScale)
+	m := TextMetrics{
+		Ascent:  -float64(gl.Top) * math.Abs(yScale),
+		Descent: float64(gl.Bottom) * math.Abs(yScale),
+	}
+	m.Left, m.Advance = o.advance(font, text, xScale)
+	if o.Stretch != 0 {
+		xScale *= o.Stretch
+	}
+	first := true
+	for _, line := range strokes {
+		for _, pt := range line {
+			p := polygon.Point{X: pt.X * xScale, Y: pt.Y * yScale}
+			if first {
+				m.Min, m.Max = p, p
+				first = false
+				continue
+			}
+			m.Min, _ = polygon.BB(m.Min, p)
+			_, m.Max = polygon.BB(m.Max, p)
+		}
+	}
+	if !first {
+		m.Min = m.Min.AddX(polygon.Point{X: 1, Y: 1}, -wScale/2)
+		m.Max = m.Max.AddX(polygon.Point{X: 1, Y: 1}, wScale/2)
+	}
+	return m
+}
+
+// placed is a glyph positioned within a line of text.
+type placed struct {
+	// r is the rune rendered by the glyph.
+	r rune
+
+	// gl holds the glyph, with its strokes in their native font
+	// coordinates.
+	gl hershey.Glyph
+
+	// x is the position, in font units, of the gl.Left edge of the
+	// glyph within the line.
+	x float64
+}
+
+// layout positions each glyph of text in the same way as
+// (*hershey.Font).Text. It returns the glyphs and the left and right
+// edges of the advance width of the line, in font units. Like
+// (*hershey.Font).Text, glyphs without strokes that precede the
+// first inked glyph are dropped, unless blanks is true.
+func layout(font *hershey.Font, text string, blanks bool) (gs []placed, left, right float64) {
+	started := false
+	for _, r := range text {
+		gl, _, _ := font.Text(string(r))
+		if !started {
+			inked := blanks
+			for _, line := range gl.Strokes {
+				inked = inked || len(line) != 0
+			}
+			if !inked {
+				continue
+			}
+			started = true
+			left, right = float64(gl.Left), float64(gl.Left)
+		}
+		gs = append(gs, placed{r: r, gl: gl, x: right})
+		right += float64(gl.Right - gl.Left)
+	}
+	return
+}
+
+// spaced lays out text as layout does, adjusted by the letter spacing
+// options of opts. The output unit spacings are converted to font
+// units with xScale.
+func (opts *TextOptions) spaced(font *hershey.Font, text string, xScale float64) (gs []placed, left, right float64) {
+	// Leading blanks pad the cells of monospaced text, such as
+	// the columns of a table.
+	gs, left, right = layout(font, text, opts.Monospace != 0)
+	if opts.Tracking == 0 && len(opts.Kerning) == 0 && opts.Monospace == 0 {
+		return
+	}
+	track := opts.Tracking / xScale
+	if opts.Monospace != 0 {
+		// The cells start at the origin, whatever the first
+		// glyph.
+		cell := opts.Monospace / xScale
+		for i := range gs {
+			g := &gs[i]
+			start := float64(i) * (cell + track)
+			g.x = start + (cell-float64(g.gl.Right-g.gl.Left))/2
+		}
+		left, right = 0, 0
+		if n := float64(len(gs)); n != 0 {
+			right = n*cell + (n-1)*track
+		}
+		return
+	}
+	shift := 0.0
+	for i := range gs {
+		if i != 0 {
+			shift += track + opts.Kerning[[2]rune{gs[i-1].r, gs[i].r}]/xScale
+		}
+		gs[i].x += shift
+	}
+	right += shift
+	return
+}
+
+// strokes returns the strokes of text, as laid out by spaced, in font
+// units. It also returns the horizontal extremes of the strokes.
+func (opts *TextOptions) strokes(font *hershey.Font, text string, xScale float64) (strokes [][]polygon.Point, xL, xR float64) {
+	gs, _, _ := opts.spaced(font, text, xScale)
+	first := true
+	for _, g := range gs {
+		for _, line := range g.gl.Strokes {
+			var pts []polygon.Point
+			for _, pt := range line {
+				p := polygon.Point{X: float64(pt[0]-g.gl.Left) + g.x, Y: float64(pt[1])}
+				if first || p.X < xL {
+					xL = p.X
+				}
+				if first || p.X > xR {
+					xR = p.X
+				}
+				first = false
+				pts = append(pts, p)
+			}
+			strokes = append(strokes, pts)
+		}
+	}
+	return
+}
+
+// FitText computes the scale at which text, rendered by
+// (*Pen).TextWith with opts, has an ink bounding box, including the
+// width of its lines, that exactly fits within width and height. A
+// zero width or height is unconstrained, and the text exactly fits
+// the other dimension. When both are given, the text exactly fits
+// one of them. If condense is true and a height is given, the scale
+// is chosen to fit the height exactly, and the returned stretch is
+// the factor by which the text must be horizontally condensed (or
+// stretched) to exactly fit the width as well. Otherwise the
+// returned stretch is opts.Stretch, or 1 if that is zero. The
+// returned values are intended to be used as the scale argument of,
+// and Stretch option for, (*Pen).TextWith.
+//
+// The size of glyphs rendered at a scale of 1 or less is fixed, so
+// text that does not fit at a scale of 1 can only be made to fit by
+// condensing it. Without condensing, such text returns ErrOverflow.
+// Text without strokes, no width and height, or opts with a non-zero
+// Height, which fixes the size of the glyphs, has no solution. Nor
+// does text with a fixed Weight whose ink has no extent in a
+// dimension it is fitted to, such as the height of a hyphen.
+func (pen *Pen) FitText(width, height float64, condense bool, font *hershey.Font, text string, opts *TextOptions) (scale, stretch float64, err error) {
+	o := TextOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	stretch = o.Stretch
+	if stretch == 0 {
+		stretch = 1
+	}
+	if (width <= 0 && height <= 0) || o.Height != 0 {
+		return 0, stretch, ErrNoSolution
+	}
+	var top, bottom float64
+	first := true
+	strokes, _, _ := o.strokes(font, text, pen.Scribe)
+	for _, line := range strokes {
+		for _, pt := range line {
+			if first || pt.Y < top {
+				top = pt.Y
+			}
+			if first || pt.Y > bottom {
+				bottom = pt.Y
+			}
+			first = false
+		}
+	}
+	if first {
+		return 0, stretch, ErrNoSolution
+	}
+	// inkWidth is the unstretched width of the ink, less the line
+	// width, at scale.
+	inkWidth := func(scale float64) float64 {
+		xScale, _, _ := o.scales(pen, scale, font)
+		_, xL, xR := o.strokes(font, text, xScale)
+		return (xR - xL) * xScale
+	}
+	if o.Weight > 0 && ((height > 0 && bottom == top) || (width > 0 && inkWidth(1) == 0)) {
+		return 0, stretch, ErrNoSolution
+	}
+	// The ink at scale s >= 1 is (bottom-top)*xScale + wScale
+	// high, where xScale = s*pen.Scribe and wScale = 1.8*xScale,
+	// or o.Weight.
+	fitHeight := height / (pen.Scribe * (bottom - top + 1.8))
+	if o.Weight > 0 {
+		fitHeight = (height - o.Weight) / (pen.Scribe * (bottom - top))
+	}
+	if height > 0 && condense {
+		if fitHeight < 1 {
+			return 1, stretch, ErrOverflow
+		}
+		scale = fitHeight
+		if width > 0 {
+			_, _, wScale := o.scales(pen, scale, font)
+			if ink := inkWidth(scale); ink > 0 {
+				stretch = (width - wScale) / ink
+			}
+			if stretch <= 0 {
+				return scale, stretch, ErrOverflow
+			}
+		}
+		return scale, stretch, nil
+	}
+	fits := func(scale float64) bool {
+		_, _, wScale := o.scales(pen, scale, font)
+		return inkWidth(scale)*stretch+wScale <= width
+	}
+	scale = math.Inf(1)
+	if width > 0 {
+		if !fits(1) {
+			if !condense {
+				return 1, stretch, ErrOverflow
+			}
+			// Only condensing can make this fit.
+			_, _, wScale := o.scales(pen, 1, font)
+			stretch = (width - wScale) / inkWidth(1)
+			if stretch <= 0 {
+				return 1, stretch, ErrOverflow
+			}
+			return 1, stretch, nil
+		}
+		lo, hi := 1.0, 2.0
+		for fits(hi) {
+			lo, hi = hi, 2*hi
+		}
+		for i := 0; i < 100 && lo < hi; i++ {
+			mid := (lo + hi) / 2
+			if mid == lo || mid == hi {
+				break
+			}
+			if fits(mid) {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		scale = lo
+	}
+	if height > 0 {
+		if fitHeight < 1 {
+			return 1, stretch, ErrOverflow
+		}
+		scale = math.Min(scale, fitHeight)
+	}
+	return scale, stretch, nil
+}
@@ -0,0 +1,434 @@
+package polymark
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"zappem.net/pub/graphics/hershey"
+	"zappem.net/pub/math/polygon"
+)
+
+// same confirms that a and b hold identical polygons, to within
+// rounding errors.
+func same(a, b *polygon.Shapes) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if len(a.P) != len(b.P) {
+		return false
+	}
+	for i, p := range a.P {
+		q := b.P[i]
+		if p.Hole != q.Hole || len(p.PS) != len(q.PS) {
+			return false
+		}
+		for j, pt := range p.PS {
+			if !polygon.MatchPoint(pt, q.PS[j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestTextBlock(t *testing.T) {
+	pen := &Pen{Scribe: 1}
+	font, err := hershey.New("futural")
+	if err != nil {
+		t.Fatalf("unable to load font: %v", err)
+	}
+	for _, a := range []Alignment{AlignLeft | AlignAbove, AlignCenter | AlignMiddle, AlignRight | AlignBelow} {
+		want := pen.Text(nil, 3, 4, .3, a, font, "Hello")
+		got := pen.TextBlock(nil, 3, 4, .3, 1.5, a, font, "Hello")
+		if !same(got, want) {
+			t.Errorf("single line block differs for alignment %d", a)
+		}
+	}
+
+	// The first line is an H and the last a g, so the ink of the
+	// block spans the extent of "Hg" plus two line pitches.
+	gl, _, _ := font.Text("Hg")
+	ll, tr := pen.Text(nil, 0, 0, .3, 0, font, "Hg").BB()
+	ink := tr.Y - ll.Y
+	top, _ := pen.Text(nil, 0, 100, .3, AlignAbove, font, "H").BB()
+	_, bottom := pen.Text(nil, 0, 100, .3, AlignBelow, font, "g").BB()
+	ts := []struct {
+		a       Alignment
+		spacing float64
+	}{
+		{AlignLeft | AlignAbove, 0},
+		{AlignCenter | AlignBelow, 0},
+		{AlignRight | AlignBelow, 2},
+		{AlignLeft | AlignMiddle, 1.5},
+	}
+	for i, v := range ts {
+		s := pen.TextBlock(nil, 0, 100, .3, v.spacing, v.a, font, "H\nH\r\ng")
+		ll, tr := s.BB()
+		spacing := v.spacing
+		if spacing == 0 {
+			spacing = 1
+		}
+		pitch := spacing * float64(gl.Bottom-gl.Top)
+		if d := tr.Y - ll.Y; math.Abs(d-2*pitch-ink) > 1e-6 {
+			t.Errorf("[%d] block height got=%g want=%g", i, d, 2*pitch+ink)
+		}
+		switch v.a &^ 3 {
+		case AlignAbove:
+			if math.Abs(ll.Y-top.Y) > 1e-6 {
+				t.Errorf("[%d] block top at %g, want %g", i, ll.Y, top.Y)
+			}
+		case AlignBelow:
+			if math.Abs(tr.Y-bottom.Y) > 1e-6 {
+				t.Errorf("[%d] block bottom at %g, want %g", i, tr.Y, bottom.Y)
+			}
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	pen := &Pen{Scribe: 1}
+	font, err := hershey.New("futural")
+	if err != nil {
+		t.Fatalf("unable to load font: %v", err)
+	}
+	ts := []struct {
+		width float64
+		text  string
+		want  []string
+		err   error
+	}{
+		{160, "the quick brown fox", []string{"the quick", "brown fox"}, nil},
+		{160, "the  quick\n\nbrown", []string{"the quick", "", "brown"}, nil},
+		{160, "an extra\u00adordinary day", []string{"an extra-", "ordinary", "day"}, nil},
+		{210, "extra\u00adordinary", []string{"extraordinary"}, nil},
+		{130, "extra\u00adordinary", []string{"extra-", "ordinary"}, nil},
+		{110, "extra\u00adordinary", []string{"extra-", "ordinary"}, ErrOverflow},
+		{100, "extraordinary", []string{"extraordinary"}, ErrOverflow},
+	}
+	for i, v := range ts {
+		got, err := pen.Wrap(1, v.width, font, v.text)
+		if err != v.err {
+			t.Errorf("[%d] got err=%v want %v", i, err, v.err)
+		}
+		if strings.Join(got, "|") != strings.Join(v.want, "|") {
+			t.Errorf("[%d] got %q want %q", i, got, v.want)
+		}
+	}
+}
+
+func TestTextBox(t *testing.T) {
+	pen := &Pen{Scribe: 1}
+	font, err := hershey.New("futural")
+	if err != nil {
+		t.Fatalf("unable to load font: %v", err)
+	}
+	text := "the quick brown fox"
+	a, b := polygon.Point{0, 0}, polygon.Point{170, 100}
+	for _, align := range []Alignment{AlignLeft | AlignAbove, AlignCenter | AlignMiddle, AlignRight | AlignBelow} {
+		s, scale, err := pen.TextBox(nil, a, b, 1, 1, align, false, font, text)
+		if err != nil || scale != 1 {
+			t.Fatalf("[%d] got scale=%g err=%v", align, scale, err)
+		}
+		ll, tr := s.BB()
+		if ll.X < a.X || ll.Y < a.Y || tr.X > b.X || tr.Y > b.Y {
+			t.Errorf("[%d] text BB %v,%v outside box", align, ll, tr)
+		}
+		switch align & 3 {
+		case AlignLeft:
+			if ll.X > 10 {
+				t.Errorf("[%d] left aligned text starts at %g", align, ll.X)
+			}
+		case AlignRight:
+			if tr.X < 160 {
+				t.Errorf("[%d] right aligned text ends at %g", align, tr.X)
+			}
+		}
+	}
+	if s, _, err := pen.TextBox(nil, a, b, 2, 1, AlignLeft, false, font, text); err != ErrOverflow || s != nil {
+		t.Errorf("got err=%v, want %v", err, ErrOverflow)
+	}
+	s, scale, err := pen.TextBox(nil, a, b, 2, 1, AlignLeft, true, font, text)
+	if err != nil || scale <= 1 || scale >= 2 {
+		t.Fatalf("shrink got scale=%g err=%v", scale, err)
+	}
+	if ll, tr := s.BB(); ll.X < a.X || ll.Y < a.Y || tr.X > b.X || tr.Y > b.Y {
+		t.Errorf("shrunk text BB %v,%v outside box", ll, tr)
+	}
+}
+
+func TestMeasureText(t *testing.T) {
+	font, err := hershey.New("futural")
+	if err != nil {
+		t.Fatalf("unable to load font: %v", err)
+	}
+	for _, reflect := range []bool{false, true} {
+		pen := &Pen{Scribe: 0.5, Reflect: reflect}
+		for _, scale := range []float64{0.5, 1, 2.5} {
+			m := pen.MeasureText(scale, font, "Hg ")
+			ll, tr := pen.Text(nil, 0, 0, scale, AlignLeft, font, "Hg ").BB()
+			// The round ends of the rendered lines are polygons
+			// that fall just inside the ink bounding box.
+			tol := 0.02 * scale
+			if math.Abs(ll.X-m.Min.X) > tol || math.Abs(ll.Y-m.Min.Y) > tol || math.Abs(tr.X-m.Max.X) > tol || math.Abs(tr.Y-m.Max.Y) > tol {
+				t.Errorf("reflect=%v scale=%g got ink %v,%v want %v,%v", reflect, scale, m.Min, m.Max, ll, tr)
+			}
+			gl, _, _ := font.Text("Hg ")
+			xScale := pen.Scribe * math.Max(scale, 1)
+			if want := float64(gl.Right-gl.Left) * xScale; m.Advance != want {
+				t.Errorf("reflect=%v scale=%g got advance=%g want %g", reflect, scale, m.Advance, want)
+			}
+			if m.Ascent <= 0 || m.Descent <= 0 || m.Ascent+m.Descent != float64(gl.Bottom-gl.Top)*xScale {
+				t.Errorf("reflect=%v scale=%g got ascent=%g descent=%g", reflect, scale, m.Ascent, m.Descent)
+			}
+			// Text aligned above is shifted by the ascent, and
+			// text aligned below by the descent.
+			sign := 1.0
+			if reflect {
+				sign = -1
+			}
+			if above, _ := pen.Text(nil, 0, 0, scale, AlignAbove, font, "Hg ").BB(); math.Abs(above.Y-ll.Y-sign*m.Ascent) > 1e-9 {
+				t.Errorf("reflect=%v scale=%g above text shifted by %g, want %g", reflect, scale, above.Y-ll.Y, sign*m.Ascent)
+			}
+			if below, _ := pen.Text(nil, 0, 0, scale, AlignBelow, font, "Hg ").BB(); math.Abs(ll.Y-below.Y-sign*m.Descent) > 1e-9 {
+				t.Errorf("reflect=%v scale=%g below text shifted by %g, want %g", reflect, scale, ll.Y-below.Y, sign*m.Descent)
+			}
+		}
+	}
+}
+
+func TestTextRotate(t *testing.T) {
+	pen := &Pen{Scribe: 1, Reflect: true}
+	font, err := hershey.New("futural")
+	if err != nil {
+		t.Fatalf("unable to load font: %v", err)
+	}
+	at := polygon.Point{10, 20}
+	for _, a := range []Alignment{AlignLeft | AlignMiddle, AlignCenter | AlignAbove, AlignRight | AlignBelow} {
+		want := pen.Text(nil, at.X, at.Y, 1.5, a, font, "Up")
+		if got := pen.TextWith(nil, at.X, at.Y, 1.5, a, font, "Up", &TextOptions{}); !same(got, want) {
+			t.Errorf("[%d] unrotated text differs", a)
+		}
+		wLL, wTR := want.BB()
+		got := pen.TextWith(nil, at.X, at.Y, 1.5, a, font, "Up", &TextOptions{Angle: math.Pi / 2})
+		ll, tr := got.BB()
+		// A quarter turn counter-clockwise about at maps (x,y)
+		// to (at.X-(y-at.Y), at.Y+(x-at.X)).
+		wantLL := polygon.Point{at.X - (wTR.Y - at.Y), at.Y + (wLL.X - at.X)}
+		wantTR := polygon.Point{at.X - (wLL.Y - at.Y), at.Y + (wTR.X - at.X)}
+		if !polygon.MatchPoint(ll, wantLL) || !polygon.MatchPoint(tr, wantTR) {
+			t.Errorf("[%d] rotated BB got %v,%v want %v,%v", a, ll, tr, wantLL, wantTR)
+		}
+		got = pen.TextWith(nil, at.X, at.Y, 1.5, a, font, "Up", &TextOptions{Angle: math.Pi})
+		ll, tr = got.BB()
+		wantLL = polygon.Point{2*at.X - wTR.X, 2*at.Y - wTR.Y}
+		wantTR = polygon.Point{2*at.X - wLL.X, 2*at.Y - wLL.Y}
+		if !polygon.MatchPoint(ll, wantLL) || !polygon.MatchPoint(tr, wantTR) {
+			t.Errorf("[%d] inverted BB got %v,%v want %v,%v", a, ll, tr, wantLL, wantTR)
+		}
+	}
+}
+
+func TestTextSpacing(t *testing.T) {
+	pen := &Pen{Scribe: 1}
+	font, err := hershey.New("futural")
+	if err != nil {
+		t.Fatalf("unable to load font: %v", err)
+	}
+	width := func(text string, opts *TextOptions) float64 {
+		ll, tr := pen.TextWith(nil, 0, 0, 2, AlignLeft, font, text, opts).BB()
+		return tr.X - ll.X
+	}
+	ts := []struct {
+		text  string
+		opts  *TextOptions
+		delta float64
+	}{
+		{"HHH", &TextOptions{Tracking: 5}, 10},
+		{"HHH", &TextOptions{Tracking: -2.5}, -5},
+		{"AVA", &TextOptions{Kerning: map[[2]rune]float64{{'A', 'V'}: -3}}, -3},
+		{"AVA", &TextOptions{Tracking: 1, Kerning: map[[2]rune]float64{{'A', 'V'}: -3, {'V', 'A'}: -2}}, -3},
+	}
+	for i, v := range ts {
+		if got := width(v.text, v.opts) - width(v.text, nil); math.Abs(got-v.delta) > 1e-9 {
+			t.Errorf("[%d] width changed by %g, want %g", i, got, v.delta)
+		}
+	}
+	for _, text := range []string{"iii", "WiW", "1.1"} {
+		// Every glyph is centered in its cell.
+		first, last := string(text[0]), string(text[len(text)-1])
+		want := 60 + (width(first, nil)+width(last, nil))/2
+		if got := width(text, &TextOptions{Monospace: 30}); math.Abs(got-want) > 1e-9 {
+			t.Errorf("monospace %q width got=%g want=%g", text, got, want)
+		}
+		if got := width(text, &TextOptions{Monospace: 30, Tracking: 2}); math.Abs(got-want-4) > 1e-9 {
+			t.Errorf("tracked monospace %q width got=%g want=%g", text, got, want+4)
+		}
+	}
+	// Leading spaces pad monospaced columns.
+	for _, opts := range []*TextOptions{{Monospace: 30}, {Monospace: 30, Tracking: 2}} {
+		left := func(text string) float64 {
+			ll, _ := pen.TextWith(nil, 0, 0, 2, AlignLeft, font, text, opts).BB()
+			return ll.X
+		}
+		if got, want := left("  1")-left("100"), 2*(opts.Monospace+opts.Tracking); math.Abs(got-want) > 1e-9 {
+			t.Errorf("%+v: \"  1\" is %g right of \"100\", want %g", *opts, got, want)
+		}
+	}
+}
+
+func TestFitText(t *testing.T) {
+	pen := &Pen{Scribe: 0.5}
+	font, err := hershey.New("futural")
+	if err != nil {
+		t.Fatalf("unable to load font: %v", err)
+	}
+	size := func(scale float64, opts *TextOptions, text string) (float64, float64) {
+		ll, tr := pen.TextWith(nil, 0, 0, scale, AlignLeft, font, text, opts).BB()
+		return tr.X - ll.X, tr.Y - ll.Y
+	}
+	ts := []struct {
+		text            string
+		width, height   float64
+		condense        bool
+		opts            *TextOptions
+		fitW, fitH, err bool
+	}{
+		{text: "Hello, World", width: 200, fitW: true},
+		{text: "Hello, World", width: 200, opts: &TextOptions{Tracking: 2}, fitW: true},
+		{text: "Hello, World", height: 20, fitH: true},
+		{text: "Hello, World", height: 20, opts: &TextOptions{Weight: 2}, fitH: true},
+		{text: "Hello, World", width: 200, opts: &TextOptions{Weight: 0.2}, fitW: true},
+		{text: "Hello, World", width: 200, height: 40, fitW: true},
+		{text: "Hi", width: 100, height: 20, fitH: true},
+		{text: "Hello, World", width: 60, height: 20, condense: true, fitW: true, fitH: true},
+		{text: "Hi", width: 100, height: 20, condense: true, fitW: true, fitH: true},
+		{text: "Hello, World", width: 1, err: true},
+		{text: "   ", width: 100, err: true},
+		{text: "Hello, World", width: 200, opts: &TextOptions{Height: 5}, err: true},
+		// With a fixed weight, ink without height or width
+		// does not grow in that dimension.
+		{text: "-", height: 5, opts: &TextOptions{Weight: 1}, err: true},
+		{text: "l", width: 10, opts: &TextOptions{Weight: 1}, err: true},
+		{text: "-", width: 10, opts: &TextOptions{Weight: 1}, fitW: true},
+	}
+	for i, v := range ts {
+		scale, stretch, err := pen.FitText(v.width, v.height, v.condense, font, v.text, v.opts)
+		if v.err {
+			if err == nil {
+				t.Errorf("[%d] got scale=%g, want error", i, scale)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("[%d] failed: %v", i, err)
+			continue
+		}
+		opts := TextOptions{}
+		if v.opts != nil {
+			opts = *v.opts
+		}
+		opts.Stretch = stretch
+		w, h := size(scale, &opts, v.text)
+		if w > v.width*(1+1e-3) && v.width != 0 {
+			t.Errorf("[%d] too wide: got=%g want<=%g", i, w, v.width)
+		}
+		if h > v.height*(1+1e-3) && v.height != 0 {
+			t.Errorf("[%d] too high: got=%g want<=%g", i, h, v.height)
+		}
+		if v.fitW && math.Abs(w-v.width) > 1e-3*v.width {
+			t.Errorf("[%d] width got=%g want=%g", i, w, v.width)
+		}
+		if v.fitH && math.Abs(h-v.height) > 1e-3*v.height {
+			t.Errorf("[%d] height got=%g want=%g", i, h, v.height)
+		}
+	}
+}
+
+func TestTextHeightWeight(t *testing.T) {
+	pen := &Pen{Scribe: 0.5}
+	font, err := hershey.New("futural")
+	if err != nil {
+		t.Fatalf("unable to load font: %v", err)
+	}
+	if !same(pen.Text(nil, 3, 4, 2, AlignLeft, font, "Ok"), pen.TextWith(nil, 3, 4, 2, AlignLeft, font, "Ok", &TextOptions{})) {
+		t.Error("zero options changed the text")
+	}
+	for _, weight := range []float64{0.1, 0.4, 1} {
+		for _, scale := range []float64{0.5, 1, 3} {
+			opts := &TextOptions{Height: 5, Weight: weight}
+			ll, tr := pen.TextWith(nil, 0, 0, scale, AlignLeft, font, "H", opts).BB()
+			if h := tr.Y - ll.Y; math.Abs(h-5-weight) > 1e-9 {
+				t.Errorf("weight=%g scale=%g: H is %g high, want %g", weight, scale, h, 5+weight)
+			}
+		}
+	}
+	// Light and bold text of the same height have the same layout.
+	light := pen.TextWith(nil, 0, 0, 1, AlignCenter, font, "HH", &TextOptions{Height: 5, Weight: 0.2})
+	bold := pen.TextWith(nil, 0, 0, 1, AlignCenter, font, "HH", &TextOptions{Height: 5, Weight: 1})
+	ll0, tr0 := light.BB()
+	ll1, tr1 := bold.BB()
+	if d := (tr1.X - ll1.X) - (tr0.X - ll0.X); math.Abs(d-0.8) > 1e-9 {
+		t.Errorf("bold text is %g wider than light, want 0.8", d)
+	}
+}
+
+func TestTextWithOptions(t *testing.T) {
+	font, err := hershey.New("futural")
+	if err != nil {
+		t.Fatalf("unable to load font: %v", err)
+	}
+	ts := []*TextOptions{
+		{Height: 5, Weight: 0.3},
+		{Height: 2},
+		{Weight: 2},
+		{Height: 4, Tracking: 1, Stretch: 0.7},
+		{Height: 4, Monospace: 5},
+	}
+	for _, reflect := range []bool{false, true} {
+		pen := &Pen{Scribe: 0.5, Reflect: reflect}
+		for i, opts := range ts {
+			for _, scale := range []float64{0.5, 3} {
+				m := pen.MeasureTextWith(scale, font, "Hg", opts)
+				ll, tr := pen.TextWith(nil, 0, 0, scale, AlignLeft, font, "Hg", opts).BB()
+				tol := 0.01 * (tr.Y - ll.Y)
+				if math.Abs(ll.X-m.Min.X) > tol || math.Abs(ll.Y-m.Min.Y) > tol || math.Abs(tr.X-m.Max.X) > tol || math.Abs(tr.Y-m.Max.Y) > tol {
+					t.Errorf("[%d] reflect=%v scale=%g got ink %v,%v want %v,%v", i, reflect, scale, m.Min, m.Max, ll, tr)
+				}
+				sign := 1.0
+				if reflect {
+					sign = -1
+				}
+				if above, _ := pen.TextWith(nil, 0, 0, scale, AlignAbove, font, "Hg", opts).BB(); math.Abs(above.Y-ll.Y-sign*m.Ascent) > 1e-9 {
+					t.Errorf("[%d] reflect=%v scale=%g above text shifted by %g, want %g", i, reflect, scale, above.Y-ll.Y, sign*m.Ascent)
+				}
+
+				// A single line block renders as TextWith.
+				want := pen.TextWith(nil, 3, 4, scale, AlignCenter|AlignAbove, font, "Hg", opts)
+				if got := pen.TextBlockWith(nil, 3, 4, scale, 1, AlignCenter|AlignAbove, font, "Hg", opts); !same(got, want) {
+					t.Errorf("[%d] reflect=%v scale=%g single line block differs", i, reflect, scale)
+				}
+			}
+		}
+	}
+
+	// Text that fits a box at its default size overflows it when
+	// a larger Height is requested.
+	pen := &Pen{Scribe: 0.5}
+	a, b := polygon.Point{0, 0}, polygon.Point{50, 40}
+	if _, _, err := pen.TextBoxWith(nil, a, b, 1, 1, AlignLeft, false, font, "Hg Hg", nil); err != nil {
+		t.Errorf("default text box failed: %v", err)
+	}
+	if _, _, err := pen.TextBoxWith(nil, a, b, 1, 1, AlignLeft, false, font, "Hg Hg", &TextOptions{Height: 20}); err != ErrOverflow {
+		t.Errorf("tall text box got err=%v, want %v", err, ErrOverflow)
+	}
+	s, _, err := pen.TextBoxWith(nil, a, b, 1, 1, AlignRight|AlignBelow, false, font, "Hg Hg", &TextOptions{Height: 3, Weight: 0.5})
+	if err != nil {
+		t.Fatalf("text box failed: %v", err)
+	}
+	// The box holds the glyphs, but their lines can extend beyond
+	// it by half of their width.
+	if ll, tr := s.BB(); ll.X < a.X-0.25 || ll.Y < a.Y-0.25 || tr.X > b.X+0.25 || tr.Y > b.Y+0.25 {
+		t.Errorf("text BB %v,%v outside box", ll, tr)
+	}
+}
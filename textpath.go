@@ -0,0 +1,170 @@
+package polymark
+
+import (
+	"math"
+
+	"zappem.net/pub/graphics/hershey"
+	"zappem.net/pub/math/polygon"
+)
+
+// along returns the point a distance d along the line through the
+// distinct points pts, and the unit direction of the line there. The
+// line is extended along the direction of its first and last
+// segments for distances before its start or beyond its end.
+func along(pts []polygon.Point, d float64) (at, dir polygon.Point) {
+	n := len(pts)
+	for i := 1; i < n; i++ {
+		a, b := pts[i-1], pts[i]
+		l := math.Hypot(b.X-a.X, b.Y-a.Y)
+		dir, _ = a.Unit(b)
+		if d < l || i == n-1 {
+			return a.AddX(dir, d), dir
+		}
+		d -= l
+	}
+	return
+}
+
+// glyphs renders each glyph of the laid out line, gs, at scale with
+// the Height, Weight and Stretch of opts. The horizontal center of
+// each glyph's advance width is positioned by where, which is called
+// with the distance along the line of text of that center, in output
+// units, and returns the point and the unit direction of the text's
+// baseline there. The glyph Y coordinates,
+// offset by dy font units, are rendered along the left normal of the
+// baseline direction.
+func (pen *Pen) glyphs(s *polygon.Shapes, gs []placed, dy, scale float64, font *hershey.Font, opts *TextOptions, where func(d float64) (at, dir polygon.Point)) *polygon.Shapes {
+	xScale, yScale, wScale := opts.scales(pen, scale, font)
+	if opts.Stretch != 0 {
+		xScale *= opts.Stretch
+	}
+	for _, g := range gs {
+		half := float64(g.gl.Right-g.gl.Left) / 2
+		at, dir := where((g.x + half) * xScale)
+		normal := polygon.Point{X: -dir.Y, Y: dir.X}
+		for _, line := range g.gl.Strokes {
+			if len(line) == 0 {
+				continue
+			}
+			var pts []polygon.Point
+			for _, pt := range line {
+				u := (float64(pt[0]-g.gl.Left) - half) * xScale
+				v := (float64(pt[1]) + dy) * yScale
+				pts = append(pts, at.AddX(dir, u).AddX(normal, v))
+			}
+			s = pen.Line(s, pts, wScale, true, true)
+		}
+	}
+	return s
+}
+
+// anchor returns the distance along the baseline, in output units,
+// from the anchor point to the origin of text laid out by
+// opts.spaced, as aligned by the horizontal part of a. It also
+// returns the font unit offset of the glyph Y coordinates that places
+// the part of the text selected by the vertical part of a on the
+// baseline.
+func (pen *Pen) anchor(a Alignment, scale float64, font *hershey.Font, text string, opts *TextOptions) (start, dy float64) {
+	xScale, _, _ := opts.scales(pen, scale, font)
+	left, width := opts.advance(font, text, xScale)
+	switch a & 3 {
+	case AlignCenter:
+		start = -width / 2
+	case AlignRight:
+		start = -width
+	}
+	start -= left
+	gl, _, _ := font.Text(text)
+	switch a & ^3 {
+	case AlignAbove:
+		dy = -float64(gl.Top)
+	case AlignBelow:
+		dy = -float64(gl.Bottom)
+	}
+	return
+}
+
+// TextOnPath renders text along the line through the points of path,
+// with each glyph rotated to follow the direction of the path at the
+// center of the glyph's advance width. The horizontal part of the
+// Alignment places the start (AlignLeft), center (AlignCenter) or end
+// (AlignRight) of the advance width of the text a distance offset
+// along the path. Beyond the ends of the path, the text follows the
+// direction of its first and last segments. The vertical part of the
+// Alignment selects which part of the glyphs sits on the path, with
+// the same meaning as for (*Pen).Text: with AlignBelow, the glyphs
+// sit on the path on its left side (for pen.Reflect, or the right
+// side otherwise) and with AlignAbove they hang from it on the other
+// side. Reversing the direction of the path also swaps the side. A
+// path with fewer than 2 distinct points has no solution.
+func (pen *Pen) TextOnPath(s *polygon.Shapes, path []polygon.Point, offset, scale float64, a Alignment, font *hershey.Font, text string) (*polygon.Shapes, error) {
+	return pen.TextOnPathWith(s, path, offset, scale, a, font, text, nil)
+}
+
+// TextOnPathWith renders text along a path in the same way as
+// (*Pen).TextOnPath, but with the optional parameters held in opts.
+// The Angle of opts is ignored. A nil opts is equivalent to
+// (*Pen).TextOnPath.
+func (pen *Pen) TextOnPathWith(s *polygon.Shapes, path []polygon.Point, offset, scale float64, a Alignment, font *hershey.Font, text string, opts *TextOptions) (*polygon.Shapes, error) {
+	path = distinct(path)
+	if len(path) < 2 {
+		return s, ErrNoSolution
+	}
+	o := unrotated(opts)
+	xScale, _, _ := o.scales(pen, scale, font)
+	gs, _, _ := o.spaced(font, text, xScale)
+	if len(gs) == 0 {
+		return s, nil
+	}
+	start, dy := pen.anchor(a, scale, font, text, o)
+	return pen.glyphs(s, gs, dy, scale, font, o, func(d float64) (polygon.Point, polygon.Point) {
+		return along(path, offset+start+d)
+	}), nil
+}
+
+// TextOnCircle renders text around a circle of radius, centered on
+// center. The horizontal part of the Alignment places the start
+// (AlignLeft), center (AlignCenter) or end (AlignRight) of the
+// advance width of the text at angle (radians, counter-clockwise from
+// the X axis), measured along the circle. The vertical part of the
+// Alignment selects which part of the glyphs sits on the circle, with
+// the same meaning as for (*Pen).Text. If inward is false, the tops
+// of the glyphs point away from center, which is the way text reads
+// around the top of a dial, otherwise they point toward it, which is
+// the way text reads around the bottom of a dial. The reading
+// direction follows from this: outward text runs clockwise and
+// inward text runs counter-clockwise, when viewed with Y increasing
+// up the page for pen.Reflect, or down the page, as in images,
+// otherwise. A radius that is not positive has no solution.
+func (pen *Pen) TextOnCircle(s *polygon.Shapes, center polygon.Point, radius, angle, scale float64, a Alignment, inward bool, font *hershey.Font, text string) (*polygon.Shapes, error) {
+	return pen.TextOnCircleWith(s, center, radius, angle, scale, a, inward, font, text, nil)
+}
+
+// TextOnCircleWith renders text around a circle in the same way as
+// (*Pen).TextOnCircle, but with the optional parameters held in opts.
+// The Angle of opts is ignored. A nil opts is equivalent to
+// (*Pen).TextOnCircle.
+func (pen *Pen) TextOnCircleWith(s *polygon.Shapes, center polygon.Point, radius, angle, scale float64, a Alignment, inward bool, font *hershey.Font, text string, opts *TextOptions) (*polygon.Shapes, error) {
+	if radius <= 0 {
+		return s, ErrNoSolution
+	}
+	o := unrotated(opts)
+	xScale, _, _ := o.scales(pen, scale, font)
+	gs, _, _ := o.spaced(font, text, xScale)
+	if len(gs) == 0 {
+		return s, nil
+	}
+	start, dy := pen.anchor(a, scale, font, text, o)
+	// The glyph tops point along the left normal of the baseline
+	// for pen.Reflect, and along its right normal otherwise.
+	sense := -1.0
+	if pen.Reflect == inward {
+		sense = 1
+	}
+	return pen.glyphs(s, gs, dy, scale, font, o, func(d float64) (polygon.Point, polygon.Point) {
+		phi := angle + sense*(start+d)/radius
+		sin, cos := math.Sincos(phi)
+		at := polygon.Point{X: center.X + radius*cos, Y: center.Y + radius*sin}
+		return at, polygon.Point{X: -sense * sin, Y: sense * cos}
+	}), nil
+}
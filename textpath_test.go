@@ -0,0 +1,134 @@
+package polymark
+
+import (
+	"math"
+	"testing"
+
+	"zappem.net/pub/graphics/hershey"
+	"zappem.net/pub/math/polygon"
+)
+
+func TestTextOnPath(t *testing.T) {
+	font, err := hershey.New("futural")
+	if err != nil {
+		t.Fatalf("unable to load font: %v", err)
+	}
+	gl, _, _ := font.Text("on path")
+	for _, reflect := range []bool{false, true} {
+		pen := &Pen{Scribe: 1, Reflect: reflect}
+		for _, theta := range []float64{0, math.Pi / 2, 2} {
+			// A straight path from the origin renders as rotated
+			// text anchored at the origin.
+			dir := polygon.Point{math.Cos(theta), math.Sin(theta)}
+			path := []polygon.Point{{0, 0}, dir, dir.AddX(dir, 500)}
+			for _, a := range []Alignment{AlignLeft | AlignMiddle, AlignLeft | AlignAbove, AlignLeft | AlignBelow} {
+				want := pen.TextWith(nil, 0, 0, 1.5, a, font, "on path", &TextOptions{Angle: theta})
+				got, err := pen.TextOnPath(nil, path, float64(gl.Left)*1.5, 1.5, a, font, "on path")
+				if err != nil {
+					t.Fatalf("reflect=%v theta=%g a=%d failed: %v", reflect, theta, a, err)
+				}
+				if !same(got, want) {
+					t.Errorf("reflect=%v theta=%g a=%d path text differs from rotated text", reflect, theta, a)
+				}
+			}
+		}
+	}
+
+	// The text options are honored in the same way as TextWith.
+	for i, opts := range []*TextOptions{{Height: 5, Weight: 0.3}, {Height: 4, Tracking: 1, Stretch: 0.7}} {
+		pen := &Pen{Scribe: 1, Reflect: true}
+		dir := polygon.Point{math.Cos(2), math.Sin(2)}
+		path := []polygon.Point{{0, 0}, dir.AddX(dir, 500)}
+		rotated := *opts
+		rotated.Angle = 2
+		want := pen.TextWith(nil, 0, 0, 1.5, AlignLeft|AlignBelow, font, "on path", &rotated)
+		m := pen.MeasureTextWith(1.5, font, "on path", opts)
+		got, err := pen.TextOnPathWith(nil, path, m.Left, 1.5, AlignLeft|AlignBelow, font, "on path", opts)
+		if err != nil {
+			t.Fatalf("[%d] failed: %v", i, err)
+		}
+		if !same(got, want) {
+			t.Errorf("[%d] path text differs from rotated text", i)
+		}
+	}
+
+	// Centered text on a corner is symmetric about it.
+	pen := &Pen{Scribe: 1, Reflect: true}
+	path := []polygon.Point{{-100, 100}, {0, 0}, {100, 100}}
+	s, err := pen.TextOnPath(nil, path, math.Sqrt(2)*100, 1, AlignCenter|AlignAbove, font, "VAV")
+	if err != nil {
+		t.Fatalf("corner text failed: %v", err)
+	}
+	ll, tr := s.BB()
+	if math.Abs(ll.X+tr.X) > 1e-6 {
+		t.Errorf("corner text BB %v,%v is not symmetric", ll, tr)
+	}
+	if _, err := pen.TextOnPath(nil, path[:1], 0, 1, AlignLeft, font, "x"); err != ErrNoSolution {
+		t.Errorf("single point path got err=%v, want %v", err, ErrNoSolution)
+	}
+}
+
+func TestTextOnCircle(t *testing.T) {
+	font, err := hershey.New("futural")
+	if err != nil {
+		t.Fatalf("unable to load font: %v", err)
+	}
+	c := polygon.Point{50, 50}
+	for _, reflect := range []bool{false, true} {
+		pen := &Pen{Scribe: 1, Reflect: reflect}
+		m := pen.MeasureText(1, font, "L")
+		for _, inward := range []bool{false, true} {
+			for _, angle := range []float64{math.Pi / 2, -2} {
+				s, err := pen.TextOnCircle(nil, c, 100, angle, 1, AlignCenter|AlignBelow, inward, font, "L")
+				if err != nil {
+					t.Fatalf("reflect=%v inward=%v failed: %v", reflect, inward, err)
+				}
+				// Measure the radial extent and mean angle of
+				// the ink.
+				lo, hi := math.Inf(1), math.Inf(-1)
+				var sum polygon.Point
+				for _, p := range s.P {
+					for _, pt := range p.PS {
+						r := math.Hypot(pt.X-c.X, pt.Y-c.Y)
+						lo, hi = math.Min(lo, r), math.Max(hi, r)
+						sum = sum.AddX(pt.AddX(c, -1), 1/r)
+					}
+				}
+				// The bottom of the L sits on the circle, and
+				// its top points away from, or toward, the
+				// center.
+				bottom, top := lo, hi
+				if inward {
+					bottom, top = hi, lo
+				}
+				if math.Abs(bottom-100) > 1 || math.Abs(math.Abs(top-bottom)-m.Ascent-m.Descent) > 2 {
+					t.Errorf("reflect=%v inward=%v angle=%g got radial extent %g..%g", reflect, inward, angle, lo, hi)
+				}
+				if d := math.Remainder(math.Atan2(sum.Y, sum.X)-angle, 2*math.Pi); math.Abs(d) > 0.05 {
+					t.Errorf("reflect=%v inward=%v angle=%g got ink centered at %g", reflect, inward, angle, angle+d)
+				}
+			}
+		}
+	}
+	// The glyphs are sized by the text options.
+	pen := &Pen{Scribe: 1}
+	opts := &TextOptions{Height: 20, Weight: 1}
+	m := pen.MeasureTextWith(1, font, "L", opts)
+	s, err := pen.TextOnCircleWith(nil, c, 100, 0, 1, AlignCenter|AlignBelow, false, font, "L", opts)
+	if err != nil {
+		t.Fatalf("text with options failed: %v", err)
+	}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, p := range s.P {
+		for _, pt := range p.PS {
+			r := math.Hypot(pt.X-c.X, pt.Y-c.Y)
+			lo, hi = math.Min(lo, r), math.Max(hi, r)
+		}
+	}
+	if want := m.Max.Y - m.Min.Y; math.Abs(hi-lo-want) > 0.1 {
+		t.Errorf("text with options is %g high, want %g", hi-lo, want)
+	}
+	if _, err := pen.TextOnCircle(nil, c, 0, 0, 1, AlignCenter, false, font, "x"); err != ErrNoSolution {
+		t.Errorf("zero radius got err=%v, want %v", err, ErrNoSolution)
+	}
+}